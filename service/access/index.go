@@ -3,6 +3,7 @@ package access
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 func GetDomain(r *http.Request) string {
@@ -14,6 +15,16 @@ func GetDomain(r *http.Request) string {
 	return fmt.Sprintf("%s://%s", scheme, r.Host)
 }
 
+func GetClientIP(r *http.Request) string {
+	if cf := r.Header.Get("CF-Connecting-IP"); cf != "" {
+		return cf
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.Split(xff, ",")[0]
+	}
+	return strings.Split(r.RemoteAddr, ":")[0]
+}
+
 func FullURL(r *http.Request) string {
 	base := GetDomain(r)
 	return fmt.Sprintf("%s%s", base, r.RequestURI)
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"service/access"
+	"service/database"
+	"service/log"
+	"service/utils"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// most developers and mods a single batch lookup may ask for
+const maxBatchSize = 100
+
+type imageInfo struct {
+	hash   string
+	format string
+	width  int
+	height int
+}
+
+// image file info, keyed by path and modification time
+var imageInfos = cache.New(24*time.Hour, 1*time.Hour)
+
+func getImageInfo(path string) (*imageInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s@%d", path, stat.ModTime().UnixNano())
+	if val, found := imageInfos.Get(key); found {
+		return val.(*imageInfo), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	info := &imageInfo{hash: hex.EncodeToString(h.Sum(nil))}
+
+	conf, format, err := image.DecodeConfig(f)
+	if err != nil {
+		log.Warn("Failed to read dimensions of %s: %s", path, err.Error())
+	} else {
+		info.format = format
+		info.width = conf.Width
+		info.height = conf.Height
+	}
+
+	imageInfos.Set(key, info, cache.DefaultExpiration)
+
+	return info, nil
+}
+
+func imageEndpointURL(r *http.Request, dev string, format string, theme string) string {
+	out := fmt.Sprintf("%s/api/v1/image?dev=%s&fmt=%s", access.GetDomain(r), url.QueryEscape(dev), format)
+	if theme != "" {
+		out += "&theme=" + theme
+	}
+
+	return out
+}
+
+// format listed for a stored file, images are served as they are stored without transcoding
+func (info *imageInfo) rendition(fallback string) string {
+	if info.format == "" {
+		return fallback
+	}
+
+	return info.format
+}
+
+// adds the rendition of the image file, in the one format it is stored in
+func appendRenditions(r *http.Request, out *utils.Branding, dev string, img *utils.Img, theme string) error {
+	info, err := getImageInfo(filepath.Join("..", "cdn", img.FileName()))
+	if err != nil {
+		return err
+	}
+
+	format := info.rendition("webp")
+	out.Images = append(out.Images, utils.BrandingImage{
+		Format: format,
+		Scale:  1,
+		Theme:  theme,
+		URL:    imageEndpointURL(r, dev, format, theme),
+		Width:  info.width,
+		Height: info.height,
+	})
+
+	return nil
+}
+
+// batch entry for a lookup that failed, so the rest of the batch is still answered
+func failedBranding(dev string, modId string) *utils.Branding {
+	return &utils.Branding{
+		Developer: dev,
+		Mod:       modId,
+		Status:    utils.BrandingError,
+		Images:    make([]utils.BrandingImage, 0),
+		Error:     "Failed to get branding info",
+	}
+}
+
+// looks up branding metadata the same way the image endpoint resolves images, from the database and caches alone with cachedOnly set
+func lookupBranding(r *http.Request, dev string, modId string, cachedOnly bool) (*utils.Branding, error) {
+	out := &utils.Branding{
+		Developer: dev,
+		Mod:       modId,
+		Status:    utils.BrandingNone,
+		Images:    make([]utils.BrandingImage, 0),
+	}
+
+	resolve := resolveDeveloper
+	if cachedOnly {
+		resolve = resolveDeveloperCached
+	}
+
+	res, err := resolve(dev, modId)
+	if err != nil {
+		log.Debug("Failed to resolve developer %s: %s", dev, err.Error())
+		return out, nil
+	}
+
+	out.Resolved = res.Path
+
+	if res.LegacyPath != "" {
+		info, err := getImageInfo(res.LegacyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Status = utils.BrandingLegacy
+		out.Hash = info.hash
+		format := info.rendition("png")
+		out.Images = append(out.Images, utils.BrandingImage{
+			Format: format,
+			Scale:  1,
+			URL:    imageEndpointURL(r, dev, format, ""),
+			Width:  info.width,
+			Height: info.height,
+		})
+
+		return out, nil
+	}
+
+	user := res.User
+
+	out.Login = user.Login
+
+	if user.Banned {
+		return out, nil
+	}
+
+	now := time.Now()
+
+	img, err := database.GetActiveImage(user.ID, "", now)
+	if err != nil {
+		log.Debug("No image for user %s: %s", user.Login, err.Error())
+		return out, nil
+	}
+
+	updated := img.Updated()
+	out.Updated = &updated
+
+	if img.Pending {
+		out.Status = utils.BrandingPending
+		return out, nil
+	}
+
+	info, err := getImageInfo(filepath.Join("..", "cdn", img.FileName()))
+	if err != nil {
+		return nil, err
+	}
+
+	out.Status = utils.BrandingApproved
+	out.Hash = info.hash
+
+	if err := appendRenditions(r, out, user.Login, img, ""); err != nil {
+		return nil, err
+	}
+
+	// a live scheduled branding is served whatever the theme
+	if img.Scheduled() {
+		return out, nil
+	}
+
+	for _, theme := range utils.Themes {
+		variant, err := database.GetActiveImage(user.ID, theme, now)
+		if err != nil || variant.Theme() != theme {
+			continue
+		}
+
+		if err := appendRenditions(r, out, user.Login, variant, theme); err != nil {
+			log.Warn("Skipping %s variant of %s: %s", theme, user.Login, err.Error())
+		}
+	}
+
+	return out, nil
+}
+
+func init() {
+	http.HandleFunc("/api/v1/branding", func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting developer branding info...")
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			query := r.URL.Query()
+
+			dev := query.Get("dev")
+			modId := query.Get("mod")
+
+			if dev == "" && modId == "" {
+				http.Error(w, "Missing dev or mod parameter", http.StatusBadRequest)
+				return
+			}
+
+			branding, err := lookupBranding(r, dev, modId, false)
+			if err != nil {
+				log.Error("Failed to get branding info: %s", err.Error())
+				http.Error(w, "Failed to get branding info", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(branding); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	http.HandleFunc("/api/v1/branding/batch", func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting developer branding info in bulk...")
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			var body struct {
+				Developers []string `json:"developers"`
+				Mods       []string `json:"mods"`
+			}
+
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&body); err != nil {
+				log.Error("Failed to decode batch request: %s", err.Error())
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			if len(body.Developers)+len(body.Mods) > maxBatchSize {
+				http.Error(w, fmt.Sprintf("Batch exceeds %d entries", maxBatchSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			out := struct {
+				Developers map[string]*utils.Branding `json:"developers"`
+				Mods       map[string]*utils.Branding `json:"mods"`
+			}{
+				Developers: make(map[string]*utils.Branding),
+				Mods:       make(map[string]*utils.Branding),
+			}
+
+			for _, dev := range body.Developers {
+				if _, done := out.Developers[dev]; done || dev == "" {
+					continue
+				}
+
+				// a batch never waits on the Geode index or GitHub, entries they would resolve come back unresolved
+				branding, err := lookupBranding(r, dev, "", true)
+				if err != nil {
+					log.Error("Failed to get branding info for %s: %s", dev, err.Error())
+					branding = failedBranding(dev, "")
+				}
+
+				out.Developers[dev] = branding
+			}
+
+			for _, modId := range body.Mods {
+				if _, done := out.Mods[modId]; done || modId == "" {
+					continue
+				}
+
+				branding, err := lookupBranding(r, "", modId, true)
+				if err != nil {
+					log.Error("Failed to get branding info for mod %s: %s", modId, err.Error())
+					branding = failedBranding("", modId)
+				}
+
+				out.Mods[modId] = branding
+			}
+
+			log.Debug("Returning branding info for %d developers and %d mods", len(out.Developers), len(out.Mods))
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(out); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
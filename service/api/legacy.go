@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"service/log"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// disk cache for images proxied from the legacy repository, outside the public CDN folder
+var legacyCacheDir = filepath.Join("..", "cache", "legacy")
+
+// how long a proxied legacy image is served before being fetched again
+const legacyCacheTTL = 24 * time.Hour
+
+var errLegacyNotFound = errors.New("legacy image not found")
+
+var legacyClient = &http.Client{Timeout: 10 * time.Second}
+
+// developers the legacy repository has no image for
+var legacyMissing = cache.New(1*time.Hour, 10*time.Minute)
+
+var legacyNamePattern = regexp.MustCompile(`^[a-z0-9_-][a-z0-9_.-]*$`)
+
+// whether unregistered developers fall back to the legacy repository, turned off with LEGACY_FALLBACK=false once it has been imported
+func legacyEnabled() bool {
+	return os.Getenv("LEGACY_FALLBACK") != "false"
+}
+
+func legacyImageURL(dev string) string {
+	return fmt.Sprintf(
+		"https://raw.githubusercontent.com/Alphalaneous/ModDevBranding-Images/refs/heads/main/Images/%s.png",
+		dev,
+	)
+}
+
+func downloadLegacyImage(dev string, dstPath string) error {
+	resp, err := legacyClient.Get(legacyImageURL(dev))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errLegacyNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("legacy repository returned status %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(legacyCacheDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(legacyCacheDir, dev+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dstPath)
+}
+
+// returns the path of a cached legacy image, fetching it when missing or stale unless cachedOnly is set
+func getLegacyImage(dev string, cachedOnly bool) (string, error) {
+	dev = strings.ToLower(dev)
+	if !legacyEnabled() || !legacyNamePattern.MatchString(dev) {
+		return "", errLegacyNotFound
+	}
+
+	if _, found := legacyMissing.Get(dev); found {
+		return "", errLegacyNotFound
+	}
+
+	dstPath := filepath.Join(legacyCacheDir, dev+".png")
+
+	stat, statErr := os.Stat(dstPath)
+	if statErr == nil && (cachedOnly || time.Since(stat.ModTime()) < legacyCacheTTL) {
+		return dstPath, nil
+	} else if cachedOnly {
+		return "", errLegacyNotFound
+	}
+
+	err := downloadLegacyImage(dev, dstPath)
+	if errors.Is(err, errLegacyNotFound) {
+		legacyMissing.Set(dev, true, cache.DefaultExpiration)
+		os.Remove(dstPath)
+		return "", err
+	} else if err != nil {
+		if statErr == nil {
+			log.Warn("Serving stale legacy image for %s: %s", dev, err.Error())
+			return dstPath, nil
+		}
+
+		return "", err
+	}
+
+	return dstPath, nil
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+// parses a since parameter as either RFC 3339 or unix seconds
+func parseSince(since string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, since)
+}
+
+// builds the approved branding manifest keyed by developer login, only with brandings changed since a time, along with every login it lists in full
+func buildManifest(r *http.Request, since time.Time) (map[string]*utils.ManifestEntry, []string, error) {
+	imgs, err := database.ListAllImages()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imgs, err = database.FilterImagesByPending(imgs, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imgs, err = database.FilterImagesFromBannedUsers(imgs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// scheduled brandings stand in for the default one during their window, and members may only show their organization's
+	now := time.Now()
+	active, err := database.ListActiveImages(imgs, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(map[string]*utils.ManifestEntry)
+	logins := make([]string, 0, len(active))
+	for userId, img := range active {
+		if !img.LiveAt(now) {
+			continue
+		}
+
+		user, err := database.GetUser(userId)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		info, err := getImageInfo(filepath.Join("..", "cdn", img.FileName()))
+		if err != nil {
+			log.Warn("Skipping manifest entry for %s: %s", user.Login, err.Error())
+			continue
+		}
+
+		logins = append(logins, user.Login)
+
+		if !img.Updated().After(since) {
+			continue
+		}
+
+		out[user.Login] = &utils.ManifestEntry{
+			Hash:    info.hash,
+			URL:     imageEndpointURL(r, user.Login, info.rendition("webp"), ""),
+			Updated: img.Updated(),
+		}
+	}
+
+	slices.Sort(logins)
+
+	return out, logins, nil
+}
+
+func init() {
+	// incremental manifests only carry additions and changes, removed brandings are the cached logins missing from the developers list
+	http.HandleFunc("/api/v1/manifest", func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting branding manifest...")
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type, If-None-Match")
+
+		if r.Method == http.MethodGet {
+			var since time.Time
+
+			if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+				var err error
+
+				since, err = parseSince(sinceStr)
+				if err != nil {
+					http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+					return
+				}
+			}
+
+			generated := time.Now().UTC()
+
+			brandings, developers, err := buildManifest(r, since)
+			if err != nil {
+				log.Error("Failed to build manifest: %s", err.Error())
+				http.Error(w, "Failed to build manifest", http.StatusInternalServerError)
+				return
+			}
+
+			entries, err := json.Marshal(brandings)
+			if err != nil {
+				log.Error("Failed to encode manifest: %s", err.Error())
+				http.Error(w, "Failed to encode manifest", http.StatusInternalServerError)
+				return
+			}
+
+			listed, err := json.Marshal(developers)
+			if err != nil {
+				log.Error("Failed to encode manifest: %s", err.Error())
+				http.Error(w, "Failed to encode manifest", http.StatusInternalServerError)
+				return
+			}
+
+			sum := sha256.Sum256(append(entries, listed...))
+			etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:16]))
+
+			header.Set("ETag", etag)
+			header.Set("Cache-Control", "no-cache")
+
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			out := struct {
+				Generated  time.Time       `json:"generated_at"`
+				Since      *time.Time      `json:"since"`
+				Brandings  json.RawMessage `json:"brandings"`
+				Developers json.RawMessage `json:"developers"`
+			}{
+				Generated:  generated,
+				Brandings:  entries,
+				Developers: listed,
+			}
+
+			if !since.IsZero() {
+				out.Since = &since
+			}
+
+			log.Debug("Returning manifest with %d brandings", len(brandings))
+
+			header.Set("Content-Type", "application/json")
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(out); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
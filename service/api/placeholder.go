@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"service/log"
+	"service/utils"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// placeholders the image endpoint can serve for developers without branding
+const (
+	placeholderInitials = "initials" // Generated badge with the developer's initials
+	placeholderAvatar   = "avatar"   // GitHub avatar of a registered developer
+)
+
+// header naming the placeholder served instead of a branding
+const placeholderHeader = "X-Branding-Placeholder"
+
+// badge canvas size before scaling up
+const (
+	badgeSize  = 32
+	badgeScale = 8
+)
+
+var badgeColors = []color.RGBA{
+	{171, 135, 84, 255},
+	{179, 123, 170, 255},
+	{188, 186, 81, 255},
+	{84, 130, 171, 255},
+	{102, 171, 120, 255},
+	{171, 96, 84, 255},
+}
+
+// generated badges, keyed by initials and color
+var badges = cache.New(24*time.Hour, 1*time.Hour)
+
+// placeholder requested for the image, falling back to PLACEHOLDER_DEFAULT
+func placeholderKind(r *http.Request) string {
+	kind := r.URL.Query().Get("placeholder")
+	if kind == "" {
+		kind = os.Getenv("PLACEHOLDER_DEFAULT")
+	}
+
+	return kind
+}
+
+func getInitials(name string) string {
+	var out []rune
+	wordStart := true
+	for _, c := range name {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			wordStart = true
+			continue
+		}
+
+		if wordStart || (unicode.IsUpper(c) && len(out) == 1) {
+			out = append(out, unicode.ToUpper(c))
+			wordStart = false
+		}
+
+		if len(out) >= 2 {
+			break
+		}
+	}
+
+	if len(out) <= 0 {
+		return "?"
+	}
+
+	return string(out)
+}
+
+func renderBadge(initials string, bg color.RGBA) ([]byte, error) {
+	key := fmt.Sprintf("%s#%02x%02x%02x", initials, bg.R, bg.G, bg.B)
+	if val, found := badges.Get(key); found {
+		return val.([]byte), nil
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, badgeSize, badgeSize))
+	draw.Draw(small, small.Bounds(), &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	d := &font.Drawer{
+		Dst:  small,
+		Src:  image.White,
+		Face: face,
+	}
+
+	width := d.MeasureString(initials).Round()
+	d.Dot = fixed.P((badgeSize-width)/2, (badgeSize+face.Ascent-face.Descent)/2)
+	d.DrawString(initials)
+
+	big := image.NewRGBA(image.Rect(0, 0, badgeSize*badgeScale, badgeSize*badgeScale))
+	draw.NearestNeighbor.Scale(big, big.Bounds(), small, small.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, big); err != nil {
+		return nil, err
+	}
+
+	badges.Set(key, buf.Bytes(), cache.DefaultExpiration)
+
+	return buf.Bytes(), nil
+}
+
+// serves the requested placeholder for a developer without branding, reporting false if none was requested
+func servePlaceholder(w http.ResponseWriter, r *http.Request, dev string, user *utils.User) bool {
+	kind := placeholderKind(r)
+	header := w.Header()
+
+	switch kind {
+	case placeholderAvatar:
+		if user != nil && user.AvatarURL != "" {
+			header.Set(placeholderHeader, placeholderAvatar)
+			http.Redirect(w, r, user.AvatarURL, http.StatusFound)
+			return true
+		}
+
+		fallthrough
+
+	case placeholderInitials:
+		name := dev
+		if user != nil {
+			name = user.Login
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(name)))
+
+		badge, err := renderBadge(getInitials(name), badgeColors[h.Sum32()%uint32(len(badgeColors))])
+		if err != nil {
+			log.Error("Failed to render placeholder: %s", err.Error())
+			return false
+		}
+
+		header.Set(placeholderHeader, placeholderInitials)
+		header.Set("Content-Type", "image/png")
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(badge); err != nil {
+			log.Error("Failed to write placeholder: %s", err.Error())
+		}
+
+		return true
+
+	default:
+		return false
+	}
+}
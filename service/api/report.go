@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"service/access"
+	"service/database"
+	"service/log"
+	"service/moderation"
+	"service/utils"
+
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/time/rate"
+)
+
+// longest note a reporter may leave
+const maxReportNote = 500
+
+// per-IP limiters for the report endpoint, far stricter than the global limit
+var reporters = cache.New(1*time.Hour, 10*time.Minute)
+
+// mixed into reporter hashes so stored reports can't be traced back to an IP
+var reportSalt = os.Getenv("REPORT_SALT")
+
+type reportRequest struct {
+	Dev      string `json:"dev"`      // Developer name
+	Mod      string `json:"mod"`      // Mod ID
+	Category string `json:"category"` // Reason category
+	Note     string `json:"note"`     // Optional details
+	Theme    string `json:"theme"`    // Popup theme the branding was seen with, if any
+}
+
+func getReporter(ip string) *rate.Limiter {
+	if val, found := reporters.Get(ip); found {
+		return val.(*rate.Limiter)
+	}
+
+	limiter := rate.NewLimiter(rate.Every(time.Minute), 5)
+	reporters.Set(ip, limiter, cache.DefaultExpiration)
+	return limiter
+}
+
+// anonymous reporter identity, changing daily so a player can't be followed across days
+func reporterHash(ip string, imgId uint64) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%d|%s", reportSalt, ip, imgId, time.Now().UTC().Format(time.DateOnly)))
+	return hex.EncodeToString(sum[:])
+}
+
+func init() {
+	if reportSalt == "" {
+		b := make([]byte, 32)
+		rand.Read(b)
+		reportSalt = hex.EncodeToString(b)
+
+		log.Warn("REPORT_SALT is not set, duplicate reports will not be caught across restarts")
+	}
+
+	http.HandleFunc("/api/v1/report", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			ip := access.GetClientIP(r)
+			if !getReporter(ip).Allow() {
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			var req reportRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			req.Category = strings.ToLower(strings.TrimSpace(req.Category))
+			req.Note = strings.TrimSpace(req.Note)
+
+			if req.Dev == "" && req.Mod == "" {
+				http.Error(w, "Missing dev or mod", http.StatusBadRequest)
+				return
+			}
+
+			if !slices.Contains(utils.ReportCategories, req.Category) {
+				http.Error(w, fmt.Sprintf("Invalid category, expected one of %s", strings.Join(utils.ReportCategories, ", ")), http.StatusBadRequest)
+				return
+			}
+
+			if len([]rune(req.Note)) > maxReportNote {
+				http.Error(w, fmt.Sprintf("Note is longer than %d characters", maxReportNote), http.StatusBadRequest)
+				return
+			}
+
+			res, err := resolveDeveloper(req.Dev, req.Mod)
+			if err != nil || res.User == nil {
+				http.Error(w, "Branding not found", http.StatusNotFound)
+				return
+			}
+
+			img, err := database.GetActiveImage(res.User.ID, themeParam(req.Theme), time.Now())
+			if err != nil || img.Pending {
+				http.Error(w, "Branding not found", http.StatusNotFound)
+				return
+			}
+
+			count, inserted, err := database.CreateReport(img.ID, req.Category, req.Note, reporterHash(ip, img.ID))
+			if err != nil {
+				log.Error("Failed to create report: %s", err.Error())
+				http.Error(w, "Failed to create report", http.StatusInternalServerError)
+				return
+			}
+
+			if inserted {
+				log.Info("Img %d of %s reported as %s, %d open reports", img.ID, res.User.Login, req.Category, count)
+				moderation.Reported(img, req.Category, count)
+			}
+
+			// duplicates are accepted silently so reporters can't probe for them
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, "Report received")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
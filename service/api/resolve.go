@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"service/database"
+	"service/geode"
+	"service/log"
+	"service/utils"
+)
+
+// header naming the rule a developer was resolved by
+const resolutionHeader = "X-Branding-Resolution"
+
+// order resolvers are tried in when RESOLVER_CHAIN is not set
+const defaultResolverChain = "login,alias,mod,legacy"
+
+// no resolver in the chain could resolve the developer
+var errUnresolved = errors.New("developer could not be resolved")
+
+// resolver did not match the request, so the next one is tried
+var errNoMatch = errors.New("no match")
+
+// Resolved source of a developer's branding
+type resolution struct {
+	User       *utils.User // Registered user owning the branding
+	LegacyPath string      // Cached legacy image, when not registered
+	Path       string      // Rule the developer was resolved by
+}
+
+// resolves a requested developer and optional mod, returning errNoMatch to pass to the next resolver, with cachedOnly set it must not reach out to the Geode index or GitHub
+type resolver func(dev string, modId string, cachedOnly bool) (*resolution, error)
+
+var resolvers = make(map[string]resolver)
+
+// active resolvers in order
+var resolverChain []string
+
+func registerResolver(name string, r resolver) {
+	resolvers[name] = r
+}
+
+func resolveLogin(dev string, modId string, cachedOnly bool) (*resolution, error) {
+	if dev == "" {
+		return nil, errNoMatch
+	}
+
+	user, err := database.GetUserFromLogin(dev)
+	if err != nil {
+		return nil, errNoMatch
+	}
+
+	return &resolution{User: user, Path: utils.ResolveLogin}, nil
+}
+
+func resolveAlias(dev string, modId string, cachedOnly bool) (*resolution, error) {
+	if dev == "" {
+		return nil, errNoMatch
+	}
+
+	user, err := database.GetAliasUser(dev)
+	if err != nil {
+		return nil, errNoMatch
+	}
+
+	return &resolution{User: user, Path: utils.ResolveAlias}, nil
+}
+
+func resolveMod(dev string, modId string, cachedOnly bool) (*resolution, error) {
+	if modId == "" {
+		return nil, errNoMatch
+	}
+
+	// mods the index hasn't been asked about yet are left unresolved
+	if _, found := geode.Cached(modId); cachedOnly && !found {
+		return nil, errNoMatch
+	}
+
+	modDev, path, err := database.ResolveDevFromModID(geode.Default, modId, dev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mod developer: %w", err)
+	}
+
+	user, err := database.GetUserFromLogin(modDev.Username)
+	if err == nil {
+		return &resolution{User: user, Path: path}, nil
+	}
+
+	user, err = database.GetAliasUser(modDev.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &resolution{User: user, Path: path}, nil
+}
+
+func resolveLegacy(dev string, modId string, cachedOnly bool) (*resolution, error) {
+	if dev == "" {
+		return nil, errNoMatch
+	}
+
+	path, err := getLegacyImage(dev, cachedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resolution{LegacyPath: path, Path: utils.ResolveLegacy}, nil
+}
+
+// runs the resolver chain until one resolves the developer
+func resolveDeveloper(dev string, modId string) (*resolution, error) {
+	return runResolvers(dev, modId, false)
+}
+
+// runs the resolver chain from the database and caches alone, for lookups too many to wait on the network for
+func resolveDeveloperCached(dev string, modId string) (*resolution, error) {
+	return runResolvers(dev, modId, true)
+}
+
+func runResolvers(dev string, modId string, cachedOnly bool) (*resolution, error) {
+	for _, name := range resolverChain {
+		res, err := resolvers[name](dev, modId, cachedOnly)
+		if err == nil {
+			return res, nil
+		}
+
+		if !errors.Is(err, errNoMatch) {
+			log.Debug("Resolver %s failed for %s (mod %s): %s", name, dev, modId, err.Error())
+		}
+	}
+
+	return nil, errUnresolved
+}
+
+func init() {
+	registerResolver("login", resolveLogin)
+	registerResolver("alias", resolveAlias)
+	registerResolver("mod", resolveMod)
+	registerResolver("legacy", resolveLegacy)
+
+	chain := os.Getenv("RESOLVER_CHAIN")
+	if chain == "" {
+		chain = defaultResolverChain
+	}
+
+	for name := range strings.SplitSeq(chain, ",") {
+		name = strings.TrimSpace(name)
+		if _, found := resolvers[name]; found {
+			resolverChain = append(resolverChain, name)
+		} else if name != "" {
+			log.Warn("Unknown resolver %s in RESOLVER_CHAIN", name)
+		}
+	}
+
+	log.Debug("Resolving developers through %s", strings.Join(resolverChain, ", "))
+}
@@ -4,32 +4,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
+	"slices"
 	"time"
 
 	"service/database"
+	"service/impressions"
 	"service/log"
-
-	"github.com/patrickmn/go-cache"
+	"service/utils"
 )
 
-var fixedUsernames = cache.New(12*time.Hour, 1*time.Hour)
-
-func getGitUsername(repoUrl string) (string, error) {
-	u, err := url.Parse(repoUrl)
-	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
-	}
-
-	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
-	if len(parts) <= 0 {
-		return "", fmt.Errorf("invalid GitHub repo URL: %s", repoUrl)
+// theme asked of the image endpoints, unknown themes get the default branding
+func themeParam(theme string) string {
+	if slices.Contains(utils.Themes, theme) {
+		return theme
 	}
 
-	return parts[0], nil
+	return ""
 }
 
 func init() {
@@ -55,104 +47,67 @@ func init() {
 		header.Set("Access-Control-Allow-Headers", "Content-Type")
 
 		if r.Method == http.MethodGet {
-			header.Set("Content-Type", "image/webp")
-
 			query := r.URL.Query()
 
 			dev := query.Get("dev")
 			modId := query.Get("mod")
 
-			fmtParam := query.Get("fmt")
+			theme := themeParam(query.Get("theme"))
 
-			user, err := database.GetUserFromLogin(dev)
+			res, err := resolveDeveloper(dev, modId)
 			if err != nil {
-				log.Warn("Failed to get user: %s", err.Error())
-
-				if fixed, found := fixedUsernames.Get(dev); found {
-					user, err = database.GetUserFromLogin(fixed.(string))
-					if err != nil {
-						log.Error("Failed to get user: %s", err.Error())
-						http.Error(w, "Failed to get user", http.StatusNotFound)
-						return
-					}
-				} else if modId != "" {
-					mod, err := database.GetModCached(modId)
-					if err != nil {
-						log.Error("Failed to get mod: %v", err)
-						http.Error(w, "Failed to get mod", http.StatusNotFound)
-						return
-					}
-
-					modDev, err := database.ResolveDevFromModID(mod.ID, dev)
-					if err != nil {
-						log.Error("Failed to get mod developer: %v", err)
-						http.Error(w, "Failed to get mod developer", http.StatusNotFound)
-						return
-					}
+				if servePlaceholder(w, r, dev, nil) {
+					return
+				}
 
-					user, err = database.GetUserFromLogin(modDev.Username)
-					if err != nil {
-						log.Error("Failed to get user: %s", err.Error())
-						http.Error(w, "Failed to get user", http.StatusNotFound)
-						return
-					}
+				log.Error("Failed to resolve developer %s: %s", dev, err.Error())
+				http.Error(w, "Image not found", http.StatusNotFound)
+				return
+			}
 
-					username, err := getGitUsername(mod.Links.Source)
-					if err != nil {
-						log.Warn("Couldn't get GitHub username from repository URL %s", modDev.Username)
-					} else if username != "" && dev != "" && username == dev {
-						fixedUsernames.Set(username, modDev.Username, cache.DefaultExpiration)
-					} else {
-						log.Warn("Usernames %s and %s do not match or are empty", dev, modDev.Username)
-					}
-				} else {
-					devLower := strings.ToLower(dev)
-					githubURL := fmt.Sprintf(
-						"https://raw.githubusercontent.com/Alphalaneous/ModDevBranding-Images/refs/heads/main/Images/%s.png",
-						devLower,
-					)
-
-					resp, err := http.Get(githubURL)
-					if err != nil || resp.StatusCode != http.StatusOK {
-						log.Error("Image not found: %v", err)
-						http.Error(w, "Image not found", http.StatusNotFound)
-						return
-					}
-					defer resp.Body.Close()
+			header.Set(resolutionHeader, res.Path)
 
-					if fmtParam == "webp" {
-						header.Set("Content-Type", "image/webp")
-					} else {
-						header.Set("Content-Type", "image/png")
-					}
+			if res.LegacyPath != "" {
+				utils.ServeImageFile(w, r, res.LegacyPath)
+				return
+			}
 
-					w.WriteHeader(http.StatusOK)
-					if _, err := io.Copy(w, resp.Body); err != nil {
-						log.Error("Failed to stream fallback image: %v", err)
-						http.Error(w, "Failed to stream image", http.StatusInternalServerError)
-						return
-					}
+			user := res.User
 
+			// a banned member's opt-in would otherwise still serve the team branding
+			if user != nil && user.Banned {
+				if servePlaceholder(w, r, dev, nil) {
 					return
 				}
+
+				log.Debug("User %s is banned", user.Login)
+				http.Error(w, "Image not found", http.StatusNotFound)
+				return
 			}
 
 			if user != nil {
-				img, err := database.GetImageForUser(user.ID)
+				img, err := database.GetActiveImage(user.ID, theme, time.Now())
 				if err != nil {
+					if servePlaceholder(w, r, dev, user) {
+						return
+					}
+
 					log.Error("Failed to get image info: %s", err.Error())
 					http.Error(w, "Failed to get image info", http.StatusInternalServerError)
 					return
 				}
 
 				if img.Pending {
+					if servePlaceholder(w, r, dev, user) {
+						return
+					}
+
 					log.Error("Image still pending review")
 					http.Error(w, "Image still pending review", http.StatusForbidden)
 					return
 				}
 
-				fileName := fmt.Sprintf("%d.webp", user.ID)
-				dstPath := filepath.Join("..", "cdn", fileName)
+				dstPath := filepath.Join("..", "cdn", img.FileName())
 
 				log.Info("Getting brand image %s for %s", dstPath, user.Login)
 
@@ -164,27 +119,22 @@ func init() {
 				}
 				defer f.Close()
 
-				if fmtParam == "webp" {
-					header.Set("Content-Type", "image/webp")
-
-					w.WriteHeader(http.StatusOK)
-					if _, err := io.Copy(w, f); err != nil {
-						log.Error("Failed to stream image: %s", err.Error())
-						http.Error(w, "Failed to stream image", http.StatusInternalServerError)
-						return
-					}
+				impressions.Record(user.ID, modId)
 
+				// the file's own format, the fmt parameter can't turn one into another
+				contentType, err := utils.SniffImageType(f)
+				if err != nil {
+					log.Error("Failed to read image: %s", err.Error())
+					http.Error(w, "Failed to read image", http.StatusInternalServerError)
 					return
-				} else {
-					header.Set("Content-Type", "image/png")
+				}
 
-					w.WriteHeader(http.StatusOK)
-					if _, err := io.Copy(w, f); err != nil {
-						log.Error("Failed to stream image: %s", err.Error())
-						http.Error(w, "Failed to stream image", http.StatusInternalServerError)
-						return
-					}
+				header.Set("Content-Type", contentType)
 
+				w.WriteHeader(http.StatusOK)
+				if _, err := io.Copy(w, f); err != nil {
+					log.Error("Failed to stream image: %s", err.Error())
+					http.Error(w, "Failed to stream image", http.StatusInternalServerError)
 					return
 				}
 			} else {
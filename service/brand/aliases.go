@@ -0,0 +1,258 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"service/database"
+	"service/geode"
+	"service/log"
+	"service/utils"
+)
+
+// checks the Geode index lists the developer name on a mod whose source repo belongs to the user
+func verifyAliasClaim(user *utils.User, alias string, modId string) error {
+	mod, err := geode.GetMod(modId)
+	if err != nil {
+		return err
+	}
+
+	listed := false
+	for _, dev := range mod.Developers {
+		if strings.EqualFold(dev.Username, alias) {
+			listed = true
+			break
+		}
+	}
+
+	if !listed {
+		return fmt.Errorf("%s is not a developer of mod %s", alias, mod.ID)
+	}
+
+	owner, err := mod.Links.SourceOwner()
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(owner, user.Login) {
+		return fmt.Errorf("source repository of mod %s does not belong to %s", mod.ID, user.Login)
+	}
+
+	return nil
+}
+
+func init() {
+	http.HandleFunc("/brand/aliases", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+			userStr := query.Get("user")
+
+			var aliases []*utils.Alias
+			var err error
+			if u.IsAdmin && query.Get("all") == "true" {
+				aliases, err = database.ListAliases()
+			} else if u.IsAdmin && userStr != "" {
+				userId, parseErr := strconv.ParseUint(userStr, 10, 64)
+				if parseErr != nil {
+					http.Error(w, "Invalid user ID parameter", http.StatusBadRequest)
+					return
+				}
+
+				aliases, err = database.ListAliasesForUser(userId)
+			} else {
+				aliases, err = database.ListAliasesForUser(u.ID)
+			}
+
+			if err != nil {
+				log.Error("Failed to list aliases: %s", err.Error())
+				http.Error(w, "Failed to list aliases", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(aliases); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/aliases/claim", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			if u.Banned {
+				log.Error("User %s is banned", u.Login)
+				http.Error(w, "User is banned", http.StatusForbidden)
+				return
+			}
+
+			query := r.URL.Query()
+			name := query.Get("name")
+			modId := query.Get("mod")
+
+			if name == "" || modId == "" {
+				http.Error(w, "Missing name or mod parameter", http.StatusBadRequest)
+				return
+			}
+
+			if other, err := database.GetUserFromLogin(name); err == nil && other.ID != u.ID {
+				log.Warn("User %s tried to claim the login of %s", u.Login, other.Login)
+				http.Error(w, "Name belongs to another user", http.StatusConflict)
+				return
+			}
+
+			if err := verifyAliasClaim(u, name, modId); err != nil {
+				log.Warn("Rejected alias claim of %s by %s: %s", name, u.Login, err.Error())
+				http.Error(w, fmt.Sprintf("Could not verify claim: %s", err.Error()), http.StatusForbidden)
+				return
+			}
+
+			alias, err := database.CreateAlias(name, u.ID, modId, utils.NewAudit(u, utils.AuditAliasCreate, "claimed through mod "+modId))
+			if errors.Is(err, database.ErrAliasTaken) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Error("Failed to create alias: %s", err.Error())
+				http.Error(w, "Failed to create alias", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("User %s claimed developer name %s through mod %s", u.Login, alias.Alias, modId)
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(alias); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/aliases/add", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+			name := query.Get("name")
+
+			userId, err := strconv.ParseUint(query.Get("user"), 10, 64)
+			if err != nil || name == "" {
+				http.Error(w, "Missing or invalid name or user parameter", http.StatusBadRequest)
+				return
+			}
+
+			alias, err := database.CreateAlias(name, userId, "", utils.NewAudit(u, utils.AuditAliasCreate, query.Get("reason")))
+			if errors.Is(err, database.ErrAliasTaken) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Error("Failed to create alias: %s", err.Error())
+				http.Error(w, "Failed to create alias", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s linked developer name %s to user %d", u.Login, alias.Alias, userId)
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(alias); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/aliases/delete", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			name := r.URL.Query().Get("name")
+			if name == "" {
+				http.Error(w, "Missing name parameter", http.StatusBadRequest)
+				return
+			}
+
+			alias, err := database.GetAlias(name)
+			if err != nil {
+				log.Error("Failed to get alias: %s", err.Error())
+				http.Error(w, "Alias not found", http.StatusNotFound)
+				return
+			}
+
+			if !u.IsAdmin && alias.UserID != u.ID {
+				log.Error("Unauthorized alias deletion attempt for %s by user %d", alias.Alias, u.ID)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if err := database.DeleteAlias(alias.Alias, utils.NewAudit(u, utils.AuditAliasDelete, r.URL.Query().Get("reason"))); err != nil {
+				log.Error("Failed to delete alias: %s", err.Error())
+				http.Error(w, "Failed to delete alias", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("User %s removed developer name %s", u.Login, alias.Alias)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Alias deleted successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"service/database"
+	"service/log"
+	"service/moderation"
+	"service/utils"
+)
+
+// longest message an appeal may carry
+const maxAppealMessage = 2000
+
+type appealRequest struct {
+	AuditID uint64 `json:"audit_id"` // Audit entry of the contested action
+	Message string `json:"message"`  // Why the action was wrong
+}
+
+// moderation actions taken on a user that they could still appeal
+func appealableActions(u *utils.User) ([]*utils.AuditEntry, error) {
+	entries, err := database.ListAudit(utils.AuditFilter{TargetUser: u.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	appealed := make(map[uint64]bool)
+	appeals, err := database.ListAppealsForUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range appeals {
+		appealed[a.AuditID] = true
+	}
+
+	out := make([]*utils.AuditEntry, 0)
+	for _, e := range entries {
+		if e.ActorID == u.ID || appealed[e.ID] || !slices.Contains(utils.AppealableActions, e.Action) {
+			continue
+		}
+
+		if u.Banned && e.Action != utils.AuditUserBan {
+			continue
+		}
+
+		// staff identities stay private, the user only sees what happened and why
+		e.ActorID = 0
+		e.Before = nil
+		e.After = nil
+
+		out = append(out, e)
+	}
+
+	return out, nil
+}
+
+// strips staff identities from an appeal shown to its user
+func hideReviewers(a *utils.Appeal) {
+	a.ReviewerID = 0
+	for _, e := range a.Events {
+		if e.ActorID != a.UserID {
+			e.ActorID = 0
+		}
+	}
+}
+
+func init() {
+	// banned users keep access to this and the other appeal routes
+	http.HandleFunc("/brand/appeals", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			var appeals []*utils.Appeal
+			var err error
+			if moderation.IsStaff(u) && query.Get("queue") == "true" {
+				appeals, err = database.ListAppeals(query.Get("status"))
+			} else {
+				appeals, err = database.ListAppealsForUser(u.ID)
+				for _, a := range appeals {
+					hideReviewers(a)
+				}
+			}
+
+			if err != nil {
+				log.Error("Failed to list appeals: %s", err.Error())
+				http.Error(w, "Failed to list appeals", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(appeals); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/appeals/actions", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			actions, err := appealableActions(u)
+			if err != nil {
+				log.Error("Failed to list appealable actions: %s", err.Error())
+				http.Error(w, "Failed to list appealable actions", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(actions); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/appeals/submit", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			var req appealRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			req.Message = strings.TrimSpace(req.Message)
+
+			if req.AuditID == 0 || req.Message == "" {
+				http.Error(w, "Missing audit_id or message", http.StatusBadRequest)
+				return
+			}
+
+			if len([]rune(req.Message)) > maxAppealMessage {
+				http.Error(w, fmt.Sprintf("Message is longer than %d characters", maxAppealMessage), http.StatusBadRequest)
+				return
+			}
+
+			appeal, err := moderation.SubmitAppeal(u, req.AuditID, req.Message)
+			if err != nil {
+				if errors.Is(err, database.ErrNotAppealable) {
+					http.Error(w, "Action cannot be appealed", http.StatusForbidden)
+					return
+				}
+
+				if errors.Is(err, database.ErrConflict) {
+					http.Error(w, "Action was already appealed", http.StatusConflict)
+					return
+				}
+
+				log.Error("Failed to submit appeal: %s", err.Error())
+				http.Error(w, "Failed to submit appeal", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(appeal); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/appeals/update", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			id, err := strconv.ParseUint(query.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid appeal ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			appeal, err := moderation.DecideAppeal(id, query.Get("status"), u, query.Get("note"))
+			if err != nil {
+				if errors.Is(err, moderation.ErrNotAdmin) {
+					http.Error(w, "Only admins can lift bans", http.StatusUnauthorized)
+					return
+				}
+
+				if errors.Is(err, database.ErrConflict) {
+					http.Error(w, "Appeal cannot move to that status, or the action can no longer be reverted", http.StatusConflict)
+					return
+				}
+
+				log.Error("Failed to update appeal: %s", err.Error())
+				http.Error(w, "Failed to update appeal", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(appeal); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+// accepts RFC3339 or unix seconds
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s", value)
+	}
+
+	return time.Unix(secs, 0), nil
+}
+
+func parseAuditID(query url.Values, name string) (uint64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+
+	return id, nil
+}
+
+func parseAuditFilter(query url.Values) (utils.AuditFilter, error) {
+	var filter utils.AuditFilter
+	var err error
+
+	filter.Action = query.Get("action")
+
+	if filter.ActorID, err = parseAuditID(query, "actor"); err != nil {
+		return filter, err
+	}
+
+	if filter.TargetUser, err = parseAuditID(query, "user"); err != nil {
+		return filter, err
+	}
+
+	if filter.TargetImage, err = parseAuditID(query, "image"); err != nil {
+		return filter, err
+	}
+
+	if filter.Since, err = parseAuditTime(query.Get("since")); err != nil {
+		return filter, err
+	}
+
+	if filter.Until, err = parseAuditTime(query.Get("until")); err != nil {
+		return filter, err
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return filter, fmt.Errorf("invalid limit parameter")
+		}
+	}
+
+	return filter, nil
+}
+
+func init() {
+	http.HandleFunc("/brand/audit", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			filter, err := parseAuditFilter(r.URL.Query())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			entries, err := database.ListAudit(filter)
+			if err != nil {
+				log.Error("Failed to list audit log: %s", err.Error())
+				http.Error(w, "Failed to list audit log", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(entries); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
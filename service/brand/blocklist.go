@@ -0,0 +1,158 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"service/database"
+	"service/log"
+	"service/screening"
+	"service/utils"
+)
+
+// hashes to block, taken from a stored image or given as 16 hex digit phash and dhash parameters
+func getBlockHashes(r *http.Request) (uint64, uint64, uint64, error) {
+	query := r.URL.Query()
+
+	if imageStr := query.Get("image"); imageStr != "" {
+		imgId, err := strconv.ParseUint(imageStr, 10, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid image parameter")
+		}
+
+		img, err := database.GetImage(imgId)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("image %d not found", imgId)
+		}
+
+		if img.PHash != nil && img.DHash != nil {
+			return *img.PHash, *img.DHash, img.ID, nil
+		}
+
+		phash, dhash, err := screening.HashStoredImage(img)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to hash image %d", imgId)
+		}
+
+		return phash, dhash, img.ID, nil
+	}
+
+	phash, err := strconv.ParseUint(query.Get("phash"), 16, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("missing image, or invalid phash parameter")
+	}
+
+	dhash, err := strconv.ParseUint(query.Get("dhash"), 16, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid dhash parameter")
+	}
+
+	return phash, dhash, 0, nil
+}
+
+func init() {
+	http.HandleFunc("/brand/blocklist", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireStaff(w, r); !ok {
+				return
+			}
+
+			blocked, err := database.ListBlockedHashes()
+			if err != nil {
+				log.Error("Failed to list blocked hashes: %s", err.Error())
+				http.Error(w, "Failed to list blocked hashes", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(blocked); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/blocklist/add", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			phash, dhash, source, err := getBlockHashes(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			reason := r.URL.Query().Get("reason")
+
+			id, err := database.AddBlockedHash(phash, dhash, reason, source, utils.NewAudit(u, utils.AuditBlocklistAdd, reason))
+			if err != nil {
+				log.Error("Failed to block hashes: %s", err.Error())
+				http.Error(w, "Failed to block hashes", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Staff %s added blocklist entry %d", u.Login, id)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Hashes blocked successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/blocklist/delete", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid blocklist ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := database.DeleteBlockedHash(id, utils.NewAudit(u, utils.AuditBlocklistDrop, r.URL.Query().Get("reason"))); err != nil {
+				log.Error("Failed to unblock hashes: %s", err.Error())
+				http.Error(w, "Failed to unblock hashes", http.StatusNotFound)
+				return
+			}
+
+			log.Info("Staff %s removed blocklist entry %d", u.Login, id)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Hashes unblocked successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"service/log"
+	"service/moderation"
+	"service/utils"
+)
+
+// most images one bulk request may touch
+const maxBulkSize = 100
+
+type bulkRequest struct {
+	IDs    []uint64 `json:"ids"`    // Image IDs
+	Reason string   `json:"reason"` // Reason recorded for every image
+}
+
+// handles a bulk moderation endpoint for one action
+func bulkHandler(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			var req bulkRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			if len(req.IDs) <= 0 || len(req.IDs) > maxBulkSize {
+				http.Error(w, fmt.Sprintf("Expected between 1 and %d image IDs", maxBulkSize), http.StatusBadRequest)
+				return
+			}
+
+			results, err := moderation.Bulk(action, req.IDs, u, req.Reason)
+			if err != nil {
+				log.Error("Failed to bulk %s images: %s", action, err.Error())
+				http.Error(w, fmt.Sprintf("Failed to bulk %s images", action), http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(results); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func init() {
+	http.HandleFunc("/brand/bulk/approve", bulkHandler(utils.BulkApprove))
+	http.HandleFunc("/brand/bulk/reject", bulkHandler(utils.BulkReject))
+	http.HandleFunc("/brand/bulk/delete", bulkHandler(utils.BulkDelete))
+}
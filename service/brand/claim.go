@@ -0,0 +1,91 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"service/database"
+	"service/log"
+)
+
+// how long a review claim lasts unless renewed, set by CLAIM_LEASE
+var claimLease = 10 * time.Minute
+
+func init() {
+	if lease := os.Getenv("CLAIM_LEASE"); lease != "" {
+		d, err := time.ParseDuration(lease)
+		if err != nil || d <= 0 {
+			log.Warn("Invalid CLAIM_LEASE %s, using %s", lease, claimLease)
+		} else {
+			claimLease = d
+		}
+	}
+
+	http.HandleFunc("/brand/pending/claim", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST, DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		u, ok := requireStaff(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Missing or invalid img ID parameter", http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := database.ReleaseClaim(id, u.ID, u.IsAdmin); err != nil {
+				log.Error("Failed to release claim on img %d: %s", id, err.Error())
+				http.Error(w, "No claim of yours on this image", http.StatusNotFound)
+				return
+			}
+
+			log.Debug("Staff %s released img %d", u.Login, id)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Claim released successfully")
+			return
+		}
+
+		header.Set("Content-Type", "application/json")
+
+		claim, err := database.ClaimImage(id, u.ID, claimLease)
+		if errors.Is(err, database.ErrConflict) {
+			if claim != nil {
+				http.Error(w, fmt.Sprintf("Already being reviewed by %s", claim.StaffLogin), http.StatusConflict)
+			} else {
+				http.Error(w, "Image is no longer pending", http.StatusConflict)
+			}
+			return
+		} else if err != nil {
+			log.Error("Failed to claim img %d: %s", id, err.Error())
+			http.Error(w, "Failed to claim image", http.StatusInternalServerError)
+			return
+		}
+
+		log.Debug("Staff %s claimed img %d until %s", u.Login, id, claim.Expires)
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(claim); err != nil {
+			log.Error("Failed to encode response: %s", err.Error())
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			return
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package brand
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,6 +9,8 @@ import (
 	"service/access"
 	"service/database"
 	"service/log"
+	"service/moderation"
+	"service/utils"
 )
 
 func init() {
@@ -41,10 +44,9 @@ func init() {
 				return
 			}
 
-			ownerid, err := database.GetImageOwnerId(id)
+			version, err := getVersionParam(r)
 			if err != nil {
-				log.Error("Failed to get image owner: %s", err.Error())
-				http.Error(w, "Failed to get image owner", http.StatusInternalServerError)
+				http.Error(w, "Invalid version parameter", http.StatusBadRequest)
 				return
 			}
 
@@ -55,22 +57,31 @@ func init() {
 				return
 			}
 
-			if user.IsAdmin || user.IsStaff || ownerid == user.ID {
-				img, err := database.DeleteImage(id)
-				if err != nil {
-					log.Error("Failed to delete image: %s", err.Error())
-					http.Error(w, "Failed to delete image", http.StatusInternalServerError)
-					return
-				}
-
-				log.Info("Deleted image of ID %d", img.ID)
-
-				w.WriteHeader(http.StatusOK)
-				fmt.Fprint(w, "Image deleted successfully")
+			var img *utils.Img
+			if r.URL.Query().Get("abuse") == "true" {
+				img, err = moderation.RemoveAbuse(id, version, user, r.URL.Query().Get("reason"))
 			} else {
-				log.Error("Unauthorized deletion attempt for img ID %d by user %s", id, uid)
+				img, err = moderation.Delete(id, version, user, r.URL.Query().Get("reason"))
+			}
+
+			if errors.Is(err, moderation.ErrNotOwner) || errors.Is(err, moderation.ErrNotStaff) {
+				log.Error("Unauthorized deletion attempt for img ID %d by user %d", id, uid)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			} else if errors.Is(err, database.ErrConflict) {
+				log.Warn("Refused stale deletion of img %d by user %d", id, uid)
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Error("Failed to delete image: %s", err.Error())
+				http.Error(w, "Failed to delete image", http.StatusInternalServerError)
+				return
 			}
+
+			log.Info("Deleted image of ID %d", img.ID)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Image deleted successfully")
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
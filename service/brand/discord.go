@@ -0,0 +1,89 @@
+package brand
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+func init() {
+	http.HandleFunc("/brand/discord/link", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+			discordId := query.Get("discord")
+
+			userId, err := strconv.ParseUint(query.Get("user"), 10, 64)
+			if err != nil || discordId == "" {
+				http.Error(w, "Missing or invalid user or discord parameter", http.StatusBadRequest)
+				return
+			}
+
+			if _, err := strconv.ParseUint(discordId, 10, 64); err != nil {
+				http.Error(w, "Invalid discord parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := database.LinkDiscordUser(discordId, userId, utils.NewAudit(u, utils.AuditDiscordLink, query.Get("reason"))); err != nil {
+				log.Error("Failed to link Discord account: %s", err.Error())
+				http.Error(w, "Failed to link Discord account", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s linked Discord account %s to user %d", u.Login, discordId, userId)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Discord account linked successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/discord/unlink", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			userId, err := strconv.ParseUint(r.URL.Query().Get("user"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid user parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := database.UnlinkDiscordUser(userId, utils.NewAudit(u, utils.AuditDiscordUnlink, r.URL.Query().Get("reason"))); err != nil {
+				log.Error("Failed to unlink Discord account: %s", err.Error())
+				http.Error(w, "Failed to unlink Discord account", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s unlinked the Discord account of user %d", u.Login, userId)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Discord account unlinked successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+// longest staff note
+const maxNoteLength = 2000
+
+func init() {
+	http.HandleFunc("/brand/dossier", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			userId, err := strconv.ParseUint(r.URL.Query().Get("user"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid user ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			dossier, err := database.GetDossier(userId)
+			if err != nil {
+				log.Error("Failed to build dossier: %s", err.Error())
+				http.Error(w, "Failed to build dossier", http.StatusNotFound)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(dossier); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/notes", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET, POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet || r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			userId, err := strconv.ParseUint(query.Get("user"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid user ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			if r.Method == http.MethodPost {
+				note := strings.TrimSpace(query.Get("note"))
+				if note == "" || len([]rune(note)) > maxNoteLength {
+					http.Error(w, fmt.Sprintf("Note must be between 1 and %d characters", maxNoteLength), http.StatusBadRequest)
+					return
+				}
+
+				if _, err := database.GetUser(userId); err != nil {
+					http.Error(w, "User not found", http.StatusNotFound)
+					return
+				}
+
+				id, err := database.CreateNote(userId, note, utils.NewAudit(u, utils.AuditNoteCreate, ""))
+				if err != nil {
+					log.Error("Failed to create note: %s", err.Error())
+					http.Error(w, "Failed to create note", http.StatusInternalServerError)
+					return
+				}
+
+				log.Info("Staff %s added note %d on user %d", u.Login, id, userId)
+			}
+
+			notes, err := database.ListNotesForUser(userId)
+			if err != nil {
+				log.Error("Failed to list notes: %s", err.Error())
+				http.Error(w, "Failed to list notes", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(notes); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/notes/delete", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			id, err := strconv.ParseUint(query.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid note ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			// admins can remove anyone's notes, staff only their own
+			if err := database.DeleteNote(id, u.IsAdmin, utils.NewAudit(u, utils.AuditNoteDelete, query.Get("reason"))); err != nil {
+				if errors.Is(err, database.ErrConflict) {
+					http.Error(w, "Note belongs to another staff member", http.StatusUnauthorized)
+					return
+				}
+
+				log.Error("Failed to delete note: %s", err.Error())
+				http.Error(w, "Failed to delete note", http.StatusNotFound)
+				return
+			}
+
+			log.Info("Staff %s removed note %d", u.Login, id)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Note deleted successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
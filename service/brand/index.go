@@ -3,10 +3,64 @@ package brand
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
+	"service/access"
+	"service/database"
 	"service/log"
+	"service/utils"
 )
 
+// fetches the logged in user, writing an error response if there is none
+func requireUser(w http.ResponseWriter, r *http.Request) (*utils.User, bool) {
+	uid, err := access.GetSessionUserID(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	u, err := database.GetUser(uid)
+	if err != nil {
+		log.Error("Failed to get user: %s", err.Error())
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// fetches the logged in user, writing an error response unless they are admin or staff
+func requireStaff(w http.ResponseWriter, r *http.Request) (*utils.User, bool) {
+	u, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !u.IsAdmin && !u.IsStaff {
+		log.Error("User of ID %d is not admin or staff", u.ID)
+		http.Error(w, "User is not admin or staff", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// fetches the logged in user, writing an error response unless they are admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*utils.User, bool) {
+	u, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !u.IsAdmin {
+		log.Error("User of ID %d is not admin", u.ID)
+		http.Error(w, "User is not admin", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return u, true
+}
+
 func init() {
 	http.HandleFunc("/brand", func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Branding management API service pinged")
@@ -21,3 +75,13 @@ func init() {
 		fmt.Fprint(w, "pong!")
 	})
 }
+
+// optional version query parameter for optimistic concurrency, 0 when missing
+func getVersionParam(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("version")
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
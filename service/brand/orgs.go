@@ -0,0 +1,511 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"service/database"
+	"service/geode"
+	"service/log"
+	"service/utils"
+)
+
+var orgNameRegex = regexp.MustCompile(`^[a-z0-9-]{2,40}$`)
+
+// checks the Geode index lists the user on at least one of the mods
+func verifyListed(mods []string, user *utils.User) error {
+	for _, modId := range mods {
+		mod, err := geode.GetMod(modId)
+		if err != nil {
+			log.Debug("Failed to get mod %s: %s", modId, err.Error())
+			continue
+		}
+
+		if database.ModListsUser(mod, user) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not a developer of any of the organization's mods", user.Login)
+}
+
+// fetches the organization of the org parameter, writing an error response unless the user belongs to it, or owns it when owner is set
+func requireOrg(w http.ResponseWriter, r *http.Request, u *utils.User, owner bool) (*utils.Org, bool) {
+	orgId, err := strconv.ParseUint(r.URL.Query().Get("org"), 10, 64)
+	if err != nil {
+		http.Error(w, "Missing or invalid org parameter", http.StatusBadRequest)
+		return nil, false
+	}
+
+	org, err := database.GetOrg(orgId)
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	// admins manage any organization
+	if u.IsAdmin {
+		return org, true
+	}
+
+	m, err := database.GetOrgMembership(orgId, u.ID)
+	if err != nil || (owner && m.Role != utils.OrgRoleOwner) {
+		log.Error("User of ID %d does not manage organization %d", u.ID, orgId)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return org, true
+}
+
+// fetches the user of the user parameter by login
+func userParam(w http.ResponseWriter, r *http.Request) (*utils.User, bool) {
+	login := r.URL.Query().Get("user")
+	if login == "" {
+		http.Error(w, "Missing user parameter", http.StatusBadRequest)
+		return nil, false
+	}
+
+	target, err := database.GetUserFromLogin(login)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return target, true
+}
+
+func roleParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	role := r.URL.Query().Get("role")
+	if role == "" {
+		return utils.OrgRoleMember, true
+	}
+
+	if role != utils.OrgRoleMember && role != utils.OrgRoleOwner {
+		http.Error(w, "Invalid role parameter", http.StatusBadRequest)
+		return "", false
+	}
+
+	return role, true
+}
+
+// writes the error of a membership change, or reports false if there was none
+func orgError(w http.ResponseWriter, err error, what string) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, database.ErrLastOwner) || errors.Is(err, database.ErrLastMod) || errors.Is(err, database.ErrConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return true
+	}
+
+	log.Error("Failed to %s: %s", what, err.Error())
+	http.Error(w, "Failed to "+what, http.StatusInternalServerError)
+	return true
+}
+
+func writeOrg(w http.ResponseWriter, orgId uint64) {
+	org, err := database.GetOrg(orgId)
+	if err != nil {
+		log.Error("Failed to get organization: %s", err.Error())
+		http.Error(w, "Failed to get organization", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(org); err != nil {
+		log.Error("Failed to encode response: %s", err.Error())
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func init() {
+	http.HandleFunc("/brand/orgs", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			var orgs []*utils.Org
+			var err error
+			if u.IsAdmin && r.URL.Query().Get("all") == "true" {
+				orgs, err = database.ListOrgs()
+			} else {
+				orgs, err = database.ListOrgsForUser(u.ID)
+			}
+
+			if err != nil {
+				log.Error("Failed to list organizations: %s", err.Error())
+				http.Error(w, "Failed to list organizations", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(orgs); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/create", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			if u.Banned {
+				log.Error("User %s is banned", u.Login)
+				http.Error(w, "User is banned", http.StatusForbidden)
+				return
+			}
+
+			query := r.URL.Query()
+			name := query.Get("name")
+			modId := query.Get("mod")
+
+			if !orgNameRegex.MatchString(name) || modId == "" {
+				http.Error(w, "Missing mod parameter, or name is not 2 to 40 lowercase letters, digits or dashes", http.StatusBadRequest)
+				return
+			}
+
+			// a team is only real if a published mod lists it
+			if err := verifyListed([]string{modId}, u); err != nil {
+				log.Warn("Rejected organization %s by %s: %s", name, u.Login, err.Error())
+				http.Error(w, fmt.Sprintf("Could not verify organization: %s", err.Error()), http.StatusForbidden)
+				return
+			}
+
+			org, err := database.CreateOrg(name, u.ID, modId, utils.NewAudit(u, utils.AuditOrgCreate, "proven through mod "+modId))
+			if errors.Is(err, database.ErrConflict) {
+				http.Error(w, "Organization name is taken", http.StatusConflict)
+				return
+			} else if orgError(w, err, "create organization") {
+				return
+			}
+
+			log.Info("User %s founded organization %s through mod %s", u.Login, org.Name, modId)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/delete", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, true)
+			if !ok {
+				return
+			}
+
+			if orgError(w, database.DeleteOrg(org.ID, utils.NewAudit(u, utils.AuditOrgDelete, r.URL.Query().Get("reason"))), "delete organization") {
+				return
+			}
+
+			log.Info("User %s deleted organization %s", u.Login, org.Name)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Organization deleted successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/members/add", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, true)
+			if !ok {
+				return
+			}
+
+			target, ok := userParam(w, r)
+			if !ok {
+				return
+			}
+
+			role, ok := roleParam(w, r)
+			if !ok {
+				return
+			}
+
+			if target.Banned {
+				http.Error(w, "User is banned", http.StatusForbidden)
+				return
+			}
+
+			if err := verifyListed(org.Mods, target); err != nil {
+				log.Warn("Refused adding %s to organization %s: %s", target.Login, org.Name, err.Error())
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			if orgError(w, database.AddOrgMember(org.ID, target.ID, role, utils.NewAudit(u, utils.AuditOrgMemberAdd, org.Name)), "add member") {
+				return
+			}
+
+			log.Info("User %s added %s to organization %s as %s", u.Login, target.Login, org.Name, role)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// members may leave on their own, owners may remove anyone
+	http.HandleFunc("/brand/orgs/members/remove", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			target, ok := userParam(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, target.ID != u.ID)
+			if !ok {
+				return
+			}
+
+			if orgError(w, database.RemoveOrgMember(org.ID, target.ID, utils.NewAudit(u, utils.AuditOrgMemberDrop, org.Name)), "remove member") {
+				return
+			}
+
+			log.Info("User %s removed %s from organization %s", u.Login, target.Login, org.Name)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/members/role", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, true)
+			if !ok {
+				return
+			}
+
+			target, ok := userParam(w, r)
+			if !ok {
+				return
+			}
+
+			role, ok := roleParam(w, r)
+			if !ok {
+				return
+			}
+
+			if orgError(w, database.SetOrgRole(org.ID, target.ID, role, utils.NewAudit(u, utils.AuditOrgMemberRole, org.Name)), "change role") {
+				return
+			}
+
+			log.Info("User %s made %s %s of organization %s", u.Login, target.Login, role, org.Name)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/optin", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, false)
+			if !ok {
+				return
+			}
+
+			optIn := r.URL.Query().Get("enabled") != "false"
+
+			if orgError(w, database.SetOrgOptIn(org.ID, u.ID, optIn, utils.NewAudit(u, utils.AuditOrgOptIn, org.Name)), "update opt-in") {
+				return
+			}
+
+			log.Info("User %s set showing the branding of organization %s to %t", u.Login, org.Name, optIn)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/mods/add", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, true)
+			if !ok {
+				return
+			}
+
+			modId := r.URL.Query().Get("mod")
+			if modId == "" {
+				http.Error(w, "Missing mod parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := verifyListed([]string{modId}, u); err != nil {
+				http.Error(w, fmt.Sprintf("Could not verify mod: %s", err.Error()), http.StatusForbidden)
+				return
+			}
+
+			if orgError(w, database.AddOrgMod(org.ID, modId, utils.NewAudit(u, utils.AuditOrgModAdd, org.Name)), "add mod") {
+				return
+			}
+
+			log.Info("User %s listed mod %s for organization %s", u.Login, modId, org.Name)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/orgs/mods/remove", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			org, ok := requireOrg(w, r, u, true)
+			if !ok {
+				return
+			}
+
+			modId := r.URL.Query().Get("mod")
+			remaining := slices.DeleteFunc(slices.Clone(org.Mods), func(m string) bool { return m == modId })
+
+			// every member still has to be listed on a mod the organization keeps
+			for _, m := range org.Members {
+				member, err := database.GetUser(m.UserID)
+				if err != nil {
+					continue
+				}
+
+				if err := verifyListed(remaining, member); err != nil {
+					http.Error(w, err.Error(), http.StatusConflict)
+					return
+				}
+			}
+
+			if orgError(w, database.RemoveOrgMod(org.ID, modId, utils.NewAudit(u, utils.AuditOrgModDrop, org.Name)), "remove mod") {
+				return
+			}
+
+			log.Info("User %s unlisted mod %s from organization %s", u.Login, modId, org.Name)
+
+			writeOrg(w, org.ID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
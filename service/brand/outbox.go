@@ -0,0 +1,85 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+func init() {
+	http.HandleFunc("/brand/outbox", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			status := r.URL.Query().Get("status")
+			if status == "" {
+				status = utils.OutboxDead
+			}
+
+			msgs, err := database.ListOutbox(status)
+			if err != nil {
+				log.Error("Failed to list Discord outbox: %s", err.Error())
+				http.Error(w, "Failed to list Discord outbox", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(msgs); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/outbox/retry", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid message ID parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := database.RequeueOutbox(id, utils.NewAudit(u, utils.AuditOutboxRetry, "")); err != nil {
+				log.Error("Failed to requeue Discord message: %s", err.Error())
+				http.Error(w, "Failed to requeue message", http.StatusNotFound)
+				return
+			}
+
+			log.Info("Admin %s requeued Discord message %d", u.Login, id)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Message requeued successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
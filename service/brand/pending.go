@@ -2,13 +2,15 @@ package brand
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"service/access"
 	"service/database"
-	"service/discord"
 	"service/log"
+	"service/moderation"
+	"service/utils"
 )
 
 func init() {
@@ -69,13 +71,33 @@ func init() {
 				}
 			}
 
+			claims, err := database.ListClaims()
+			if err != nil {
+				log.Warn("Failed to list review claims: %s", err.Error())
+			}
+
+			votes, err := database.ListVotes()
+			if err != nil {
+				log.Warn("Failed to list approvals: %s", err.Error())
+			}
+
 			for i, img := range imgList {
+				imgList[i].Claim = claims[img.ID]
+				imgList[i].Approvals = votes[img.ID]
+
 				u, err := database.GetUser(img.UserID)
 				if err != nil {
 					log.Error("Failed to get user for img %d: %s", img.ID, err.Error())
 					continue
 				}
 				imgList[i].Login = u.Login
+
+				summary, err := database.GetDossierSummary(img.UserID)
+				if err != nil {
+					log.Warn("Failed to summarize dossier of user %d: %s", img.UserID, err.Error())
+					continue
+				}
+				imgList[i].Dossier = summary
 			}
 
 			log.Debug("Returning %d pending advertisements", len(imgList))
@@ -131,16 +153,33 @@ func init() {
 				return
 			}
 
-			img, err := database.ApproveImage(id)
+			version, err := getVersionParam(r)
 			if err != nil {
-				log.Error("Failed to approve img: %s", err.Error())
-				http.Error(w, "Failed to approve img", http.StatusInternalServerError)
+				http.Error(w, "Invalid version parameter", http.StatusBadRequest)
 				return
 			}
 
-			err = discord.WebhookAccept(img, u)
-			if err != nil {
-				log.Warn(err.Error())
+			var img *utils.Img
+			if query.Get("override") == "true" {
+				img, err = moderation.Override(id, version, u, query.Get("reason"))
+			} else {
+				img, err = moderation.Approve(id, version, u, query.Get("reason"))
+			}
+
+			if errors.Is(err, moderation.ErrNotAdmin) {
+				http.Error(w, "Only admins can override the approval quorum", http.StatusForbidden)
+				return
+			} else if errors.Is(err, moderation.ErrNoReason) {
+				http.Error(w, "Overriding the approval quorum needs a reason", http.StatusBadRequest)
+				return
+			} else if errors.Is(err, database.ErrConflict) || errors.Is(err, database.ErrAlreadyVoted) || errors.Is(err, database.ErrWindowOver) {
+				log.Warn("Refused approval of img %d by %s: %s", id, u.Login, err.Error())
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			} else if err != nil {
+				log.Error("Failed to approve img: %s", err.Error())
+				http.Error(w, "Failed to approve img", http.StatusInternalServerError)
+				return
 			}
 
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,120 @@
+package brand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"service/database"
+	"service/log"
+	"service/moderation"
+)
+
+func init() {
+	http.HandleFunc("/brand/reports", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireStaff(w, r); !ok {
+				return
+			}
+
+			groups, err := database.ListReportGroups()
+			if err != nil {
+				log.Error("Failed to list reports: %s", err.Error())
+				http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(groups); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/reports/unpublish", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			imgId, err := strconv.ParseUint(query.Get("image"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid image parameter", http.StatusBadRequest)
+				return
+			}
+
+			if _, err := moderation.Unpublish(imgId, u, query.Get("reason")); err != nil {
+				if errors.Is(err, database.ErrConflict) {
+					http.Error(w, "Image is already pending review", http.StatusConflict)
+					return
+				}
+
+				log.Error("Failed to unpublish image: %s", err.Error())
+				http.Error(w, "Failed to unpublish image", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Image unpublished successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/reports/dismiss", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireStaff(w, r)
+			if !ok {
+				return
+			}
+
+			query := r.URL.Query()
+
+			imgId, err := strconv.ParseUint(query.Get("image"), 10, 64)
+			if err != nil {
+				http.Error(w, "Missing or invalid image parameter", http.StatusBadRequest)
+				return
+			}
+
+			if err := moderation.DismissReports(imgId, u, query.Get("reason")); err != nil {
+				log.Error("Failed to dismiss reports: %s", err.Error())
+				http.Error(w, "Failed to dismiss reports", http.StatusNotFound)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Reports dismissed successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
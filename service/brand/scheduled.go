@@ -0,0 +1,121 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/moderation"
+	"service/utils"
+)
+
+// longest window a scheduled branding may run for
+const maxScheduleWindow = 90 * 24 * time.Hour
+
+// scheduled brandings a developer may have waiting or live at once
+const maxSchedules = 10
+
+var scheduleNameRegex = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+// reads the optional schedule fields of a submission into the image it will create
+func parseSchedule(r *http.Request, userId uint64) (*utils.Img, error) {
+	draft := &utils.Img{UserID: userId, Slot: utils.SlotDefault}
+
+	name := r.FormValue("schedule")
+	startsStr, endsStr := r.FormValue("starts_at"), r.FormValue("ends_at")
+	if name == "" && startsStr == "" && endsStr == "" {
+		return draft, nil
+	}
+
+	if !scheduleNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("Schedule name must be 1 to 32 lowercase letters, digits or dashes")
+	}
+
+	starts, err := time.Parse(time.RFC3339, startsStr)
+	if err != nil {
+		return nil, fmt.Errorf("Missing or invalid starts_at, expected RFC 3339")
+	}
+
+	ends, err := time.Parse(time.RFC3339, endsStr)
+	if err != nil {
+		return nil, fmt.Errorf("Missing or invalid ends_at, expected RFC 3339")
+	}
+
+	now := time.Now()
+	if !ends.After(starts) || !ends.After(now) {
+		return nil, fmt.Errorf("Schedule must end after it starts, and in the future")
+	}
+
+	if ends.Sub(starts) > maxScheduleWindow {
+		return nil, fmt.Errorf("Schedule can't run longer than %d days", int(maxScheduleWindow.Hours()/24))
+	}
+
+	draft.Slot = utils.ScheduledSlot(name)
+
+	upcoming, err := database.ListUpcomingImages(userId, now)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list scheduled brandings")
+	}
+
+	// resubmitting to an existing schedule replaces it
+	taken := 0
+	for _, img := range upcoming {
+		if img.Slot != draft.Slot {
+			taken++
+		}
+	}
+
+	if taken >= maxSchedules {
+		return nil, fmt.Errorf("Can't have more than %d scheduled brandings", maxSchedules)
+	}
+
+	starts, ends = starts.UTC(), ends.UTC()
+	draft.Starts = &starts
+	draft.Ends = &ends
+
+	return draft, nil
+}
+
+func init() {
+	http.HandleFunc("/brand/scheduled", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			userId := u.ID
+			if moderation.IsStaff(u) && r.URL.Query().Get("all") == "true" {
+				userId = 0
+			}
+
+			imgs, err := database.ListUpcomingImages(userId, time.Now())
+			if err != nil {
+				log.Error("Failed to list scheduled brandings: %s", err.Error())
+				http.Error(w, "Failed to list scheduled brandings", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(imgs); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package brand
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"service/database"
+	"service/utils"
+)
+
+// reads the optional organization, theme or schedule fields of a submission into the image it will create
+func parseSlot(r *http.Request, userId uint64) (*utils.Img, error) {
+	if orgStr := r.FormValue("org"); orgStr != "" {
+		return parseOrgSlot(orgStr, r, userId)
+	}
+
+	theme := r.FormValue("theme")
+	if theme == "" {
+		return parseSchedule(r, userId)
+	}
+
+	if r.FormValue("schedule") != "" {
+		return nil, fmt.Errorf("A theme variant can't also be scheduled")
+	}
+
+	if !slices.Contains(utils.Themes, theme) {
+		return nil, fmt.Errorf("Theme must be one of %v", utils.Themes)
+	}
+
+	// variants stand in for the default branding, which has to exist first
+	if _, err := database.GetImageForUser(userId); err != nil {
+		return nil, fmt.Errorf("Submit a default branding before adding a %s variant", theme)
+	}
+
+	return &utils.Img{UserID: userId, Slot: utils.ThemeSlot(theme)}, nil
+}
+
+// a submission of an organization's branding, which only its owners may make
+func parseOrgSlot(orgStr string, r *http.Request, userId uint64) (*utils.Img, error) {
+	if r.FormValue("theme") != "" || r.FormValue("schedule") != "" {
+		return nil, fmt.Errorf("An organization branding can't be a theme variant or scheduled")
+	}
+
+	orgId, err := strconv.ParseUint(orgStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid org parameter")
+	}
+
+	m, err := database.GetOrgMembership(orgId, userId)
+	if err != nil || m.Role != utils.OrgRoleOwner {
+		return nil, fmt.Errorf("Only owners of organization %d can submit its branding", orgId)
+	}
+
+	return &utils.Img{UserID: userId, Slot: utils.OrgSlot(orgId)}, nil
+}
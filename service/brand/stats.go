@@ -0,0 +1,126 @@
+package brand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"service/database"
+	"service/log"
+)
+
+// furthest back impression stats reach
+const maxStatsDays = 365
+
+// most entries in each top list
+const maxStatsLimit = 100
+
+// reads an optional positive number from the query, falling back to a default and capped at a maximum
+func boundedParam(r *http.Request, name string, fallback, max int) (int, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(str)
+	if err != nil || n <= 0 || n > max {
+		return 0, fmt.Errorf("Invalid %s, expected 1 to %d", name, max)
+	}
+
+	return n, nil
+}
+
+// first UTC day of a range of days ending today
+func statsSince(days int) time.Time {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return today.AddDate(0, 0, 1-days)
+}
+
+func writeStats(w http.ResponseWriter, stats any) {
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		log.Error("Failed to encode response: %s", err.Error())
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func init() {
+	// counters are flushed in batches, so the current day lags slightly behind
+	http.HandleFunc("/brand/stats", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireUser(w, r)
+			if !ok {
+				return
+			}
+
+			days, err := boundedParam(r, "days", 30, maxStatsDays)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			stats, err := database.GetImpressionStats(u.ID, statsSince(days))
+			if err != nil {
+				log.Error("Failed to get impression stats: %s", err.Error())
+				http.Error(w, "Failed to get impression stats", http.StatusInternalServerError)
+				return
+			}
+
+			writeStats(w, stats)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/stats/top", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			days, err := boundedParam(r, "days", 30, maxStatsDays)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			limit, err := boundedParam(r, "limit", 10, maxStatsLimit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			leaders, err := database.GetImpressionLeaders(statsSince(days), limit)
+			if err != nil {
+				log.Error("Failed to get impression leaders: %s", err.Error())
+				http.Error(w, "Failed to get impression leaders", http.StatusInternalServerError)
+				return
+			}
+
+			writeStats(w, leaders)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -10,8 +10,10 @@ import (
 
 	"service/access"
 	"service/database"
-	"service/discord"
 	"service/log"
+	"service/moderation"
+	"service/screening"
+	"service/utils"
 )
 
 func init() {
@@ -47,6 +49,12 @@ func init() {
 			// Parse form with 10MB limit
 			r.ParseMultipartForm(10 << 20)
 
+			draft, err := parseSlot(r, uid)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
 			// Get image file
 			file, _, err := r.FormFile("image-upload")
 			if err != nil {
@@ -56,6 +64,27 @@ func init() {
 			}
 			defer file.Close()
 
+			data, err := io.ReadAll(io.LimitReader(file, 10<<20))
+			if err != nil {
+				log.Error("Failed to read image: %s", err.Error())
+				http.Error(w, "Failed to read image", http.StatusBadRequest)
+				return
+			}
+
+			result := screening.Screen(uid, data)
+			if result.Rejected {
+				entry := utils.NewAudit(nil, utils.AuditImageScreened, screening.Describe(result))
+				entry.TargetUser = uid
+				entry.Record(nil, result)
+				if err := database.RecordAudit(entry); err != nil {
+					log.Error("Failed to record screening rejection: %s", err.Error())
+				}
+
+				log.Warn("Screening rejected upload by %s: %s", user.Login, screening.Describe(result))
+				http.Error(w, fmt.Sprintf("Submission rejected: %s", screening.Describe(result)), http.StatusUnprocessableEntity)
+				return
+			}
+
 			// Create target folder
 			targetDir := filepath.Join("..", "cdn")
 			err = os.MkdirAll(targetDir, os.ModePerm)
@@ -65,25 +94,19 @@ func init() {
 				return
 			}
 
-			fileName := fmt.Sprintf("%d.webp", uid)
+			fileName := draft.FileName()
 			dstPath := filepath.Join(targetDir, fileName)
 
-			dst, err := os.Create(dstPath)
-			if err != nil {
+			if err := os.WriteFile(dstPath, data, 0644); err != nil {
 				log.Error("Failed to save image: %s", err.Error())
 				http.Error(w, "Failed to save image", http.StatusInternalServerError)
 				return
 			}
-			defer dst.Close()
-
-			if _, err := io.Copy(dst, file); err != nil {
-				log.Error(err.Error())
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
 
 			imageURL := fmt.Sprintf("%s/cdn/%s", access.GetDomain(r), fileName)
-			imgID, err := database.CreateImage(uid, imageURL)
+			draft.ImageURL = imageURL
+
+			imgID, err := database.CreateImage(draft, result, utils.NewAudit(user, utils.AuditImageSubmit, ""))
 			if err != nil {
 				e := os.Remove(dstPath)
 				if e != nil {
@@ -96,35 +119,36 @@ func init() {
 			}
 
 			var out struct {
-				ID       uint64 `json:"id"`
-				ImageURL string `json:"image_url"`
+				ID        uint64           `json:"id"`
+				ImageURL  string           `json:"image_url"`
+				Screening *utils.Screening `json:"screening"`
 			}
 
 			out.ID = imgID
 			out.ImageURL = imageURL
+			out.Screening = result
 
 			log.Info("Saved img to %s, id=%v, user_id=%s", dstPath, imgID, uid)
 
+			held := screening.HoldsForReview(result)
+			autoApprove := !held && (user.IsAdmin || user.IsStaff || user.Verified)
+
 			img, err := database.GetImage(imgID)
 			if err != nil {
 				log.Warn(err.Error())
 			} else {
-				err = discord.WebhookStaffSubmit(img)
-				if err != nil {
-					log.Warn(err.Error())
-				}
+				moderation.Submitted(img, user, !autoApprove)
 			}
 
-			if user.IsAdmin || user.IsStaff || user.Verified {
-				newImg, err := database.ApproveImage(imgID)
+			if held {
+				log.Info("Holding img %d by %s for review, risk score %d", imgID, user.Login, result.Score)
+			} else if autoApprove {
+				newImg, err := database.ApproveImage(imgID, 0, false, utils.NewAudit(nil, utils.AuditImageApprove, "submitter is verified"))
 				if err != nil {
 					log.Error("Failed to auto-approve new img by verified user: %s", err.Error())
 				} else {
 					log.Info("Auto-approved img %s (%v) by verified user %s (%s)", newImg.ImageURL, newImg.ID, user.Login, user.ID)
-					err = discord.WebhookAccept(img, nil)
-					if err != nil {
-						log.Warn(err.Error())
-					}
+					moderation.Published(newImg, nil)
 				}
 			}
 
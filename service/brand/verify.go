@@ -6,6 +6,7 @@ import (
 	"service/access"
 	"service/database"
 	"service/log"
+	"service/utils"
 	"strconv"
 )
 
@@ -49,7 +50,7 @@ func init() {
 				return
 			}
 
-			user, err := database.VerifyUser(userId)
+			user, err := database.VerifyUser(userId, utils.NewAudit(u, utils.AuditUserVerify, query.Get("reason")))
 			if err != nil {
 				log.Error("Failed to verify user: %s", err.Error())
 				http.Error(w, "Failed to verify user", http.StatusBadRequest)
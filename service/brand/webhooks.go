@@ -0,0 +1,302 @@
+package brand
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"service/database"
+	"service/hooks"
+	"service/log"
+	"service/utils"
+)
+
+// events a subscription may ask for
+var webhookEvents = []string{
+	utils.EventBrandingSubmitted,
+	utils.EventBrandingApproved,
+	utils.EventBrandingRejected,
+	utils.EventBrandingDeleted,
+	utils.EventBrandingUnpublished,
+	utils.EventBrandingReported,
+	utils.EventBrandingRestored,
+	utils.EventBrandingExpired,
+	utils.EventUserBanned,
+	utils.EventUserUnbanned,
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func getSubscriptionParam(w http.ResponseWriter, r *http.Request) (*utils.WebhookSubscription, bool) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Missing or invalid webhook ID parameter", http.StatusBadRequest)
+		return nil, false
+	}
+
+	sub, err := database.GetSubscription(id)
+	if err != nil {
+		log.Error("Failed to get webhook: %s", err.Error())
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return sub, true
+}
+
+func init() {
+	http.HandleFunc("/brand/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			subs, err := database.ListSubscriptions()
+			if err != nil {
+				log.Error("Failed to list webhooks: %s", err.Error())
+				http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+				return
+			}
+
+			for _, sub := range subs {
+				sub.Secret = ""
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(subs); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/webhooks/create", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			header.Set("Content-Type", "application/json")
+
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			var body struct {
+				URL    string   `json:"url"`
+				Events []string `json:"events"`
+			}
+
+			if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			target, err := url.Parse(body.URL)
+			if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+				http.Error(w, "Invalid webhook URL", http.StatusBadRequest)
+				return
+			}
+
+			events := "*"
+			if len(body.Events) > 0 {
+				for _, event := range body.Events {
+					if event != "*" && !slices.Contains(webhookEvents, event) {
+						http.Error(w, fmt.Sprintf("Unknown event %s", event), http.StatusBadRequest)
+						return
+					}
+				}
+
+				events = strings.Join(body.Events, ",")
+			}
+
+			secret, err := generateWebhookSecret()
+			if err != nil {
+				log.Error("Failed to generate webhook secret: %s", err.Error())
+				http.Error(w, "Failed to generate webhook secret", http.StatusInternalServerError)
+				return
+			}
+
+			sub, err := database.CreateSubscription(target.String(), secret, events, utils.NewAudit(u, utils.AuditWebhookCreate, ""))
+			if err != nil {
+				log.Error("Failed to create webhook: %s", err.Error())
+				http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s added webhook %d for %s", u.Login, sub.ID, events)
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(sub); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/webhooks/active", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			sub, ok := getSubscriptionParam(w, r)
+			if !ok {
+				return
+			}
+
+			active := r.URL.Query().Get("active") != "false"
+			if err := database.SetSubscriptionActive(sub.ID, active, utils.NewAudit(u, utils.AuditWebhookUpdate, "")); err != nil {
+				log.Error("Failed to update webhook: %s", err.Error())
+				http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s set webhook %d active to %t", u.Login, sub.ID, active)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Webhook updated successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/webhooks/delete", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "DELETE")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodDelete {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			sub, ok := getSubscriptionParam(w, r)
+			if !ok {
+				return
+			}
+
+			if err := database.DeleteSubscription(sub.ID, utils.NewAudit(u, utils.AuditWebhookDelete, r.URL.Query().Get("reason"))); err != nil {
+				log.Error("Failed to delete webhook: %s", err.Error())
+				http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+				return
+			}
+
+			log.Info("Admin %s deleted webhook %d", u.Login, sub.ID)
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Webhook deleted successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/webhooks/ping", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "POST")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodPost {
+			u, ok := requireAdmin(w, r)
+			if !ok {
+				return
+			}
+
+			sub, ok := getSubscriptionParam(w, r)
+			if !ok {
+				return
+			}
+
+			if err := hooks.Ping(sub, u); err != nil {
+				log.Error("Failed to ping webhook: %s", err.Error())
+				http.Error(w, "Failed to ping webhook", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "Ping queued successfully")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	http.HandleFunc("/brand/webhooks/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Set("Access-Control-Allow-Methods", "GET")
+		header.Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodGet {
+			header.Set("Content-Type", "application/json")
+
+			if _, ok := requireAdmin(w, r); !ok {
+				return
+			}
+
+			sub, ok := getSubscriptionParam(w, r)
+			if !ok {
+				return
+			}
+
+			deliveries, err := database.ListDeliveries(sub.ID)
+			if err != nil {
+				log.Error("Failed to list webhook deliveries: %s", err.Error())
+				http.Error(w, "Failed to list webhook deliveries", http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+				log.Error("Failed to encode response: %s", err.Error())
+				http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
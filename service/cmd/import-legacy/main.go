@@ -0,0 +1,195 @@
+// Command import-legacy ingests a local checkout of the legacy ModDevBranding-Images repository as approved legacy brandings.
+//
+// Run it from the service directory, so images land in the same CDN folder the server uses:
+//
+//	go run ./cmd/import-legacy -repo ../ModDevBranding-Images -domain https://example.com
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"service/access"
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+var githubClient = &http.Client{Timeout: 10 * time.Second}
+
+// looks up a GitHub account that hasn't logged in here yet
+func fetchGitHubUser(login string) (*access.GitHubUser, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://api.github.com/users/%s", login), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := githubClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	user := new(access.GitHubUser)
+	if err := json.NewDecoder(resp.Body).Decode(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// finds the account a legacy image belongs to, creating it from GitHub unless this is a dry run
+func getUser(login string, lookup bool, dryRun bool) (*utils.User, error) {
+	user, err := database.GetUserFromLogin(login)
+	if err == nil || !lookup {
+		return user, err
+	}
+
+	gh, err := fetchGitHubUser(login)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return &utils.User{ID: gh.ID, Login: gh.Login, AvatarURL: gh.AvatarURL}, nil
+	}
+
+	if err := database.UpsertUser(gh.ID, gh.Login, gh.AvatarURL); err != nil {
+		return nil, err
+	}
+
+	return database.GetUser(gh.ID)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// imports a single legacy image, reporting whether anything was written
+func importImage(path string, domain string, lookup bool, dryRun bool) (bool, error) {
+	login := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	user, err := getUser(login, lookup, dryRun)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.Banned {
+		return false, fmt.Errorf("user %s is banned", user.Login)
+	}
+
+	if img, err := database.GetImageForUser(user.ID); err == nil {
+		log.Debug("Skipping %s, already has img %d", user.Login, img.ID)
+		return false, nil
+	}
+
+	if dryRun {
+		log.Info("Would import %s for %s", path, user.Login)
+		return true, nil
+	}
+
+	// stored as the PNG it is, the CDN serves whatever format a file holds
+	fileName := (&utils.Img{UserID: user.ID, Legacy: true}).FileName()
+	imageURL := fmt.Sprintf("%s/cdn/%s", strings.TrimRight(domain, "/"), fileName)
+
+	dstPath := filepath.Join("..", "cdn", fileName)
+
+	// the row is rolled back when the copy fails, and a copy is removed when the row can't be committed
+	copied := false
+	img, inserted, err := database.ImportLegacyImage(user.ID, imageURL, func() error {
+		copied = true
+		return copyFile(path, dstPath)
+	})
+	if err != nil {
+		if copied && !inserted {
+			if e := os.Remove(dstPath); e != nil && !os.IsNotExist(e) {
+				log.Error("Failed to remove copy of %s: %s", path, e.Error())
+			}
+		}
+
+		return false, fmt.Errorf("failed to import image: %w", err)
+	}
+
+	if !inserted {
+		return false, nil
+	}
+
+	log.Info("Imported legacy branding for %s as img %d", user.Login, img.ID)
+
+	return true, nil
+}
+
+func main() {
+	defer log.Shutdown()
+
+	repo := flag.String("repo", "", "path to a checkout of the ModDevBranding-Images repository")
+	domain := flag.String("domain", os.Getenv("SITE_URL"), "public site URL used for image URLs")
+	lookup := flag.Bool("github", true, "look up developers on GitHub that haven't logged in yet")
+	dryRun := flag.Bool("dry-run", false, "report what would be imported without writing to the database or CDN folder")
+	flag.Parse()
+
+	if *repo == "" || *domain == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !*dryRun {
+		if err := os.MkdirAll(filepath.Join("..", "cdn"), os.ModePerm); err != nil {
+			log.Error("Failed to get directory %s", err.Error())
+			return
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*repo, "Images", "*.png"))
+	if err != nil {
+		log.Error("Failed to list legacy images: %s", err.Error())
+		return
+	}
+
+	imported, skipped, failed := 0, 0, 0
+	for _, path := range paths {
+		done, err := importImage(path, *domain, *lookup, *dryRun)
+		if err != nil {
+			log.Warn("Failed to import %s: %s", filepath.Base(path), err.Error())
+			failed++
+		} else if done {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+
+	log.Done("Imported %d legacy brandings, skipped %d, failed %d", imported, skipped, failed)
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"service/utils"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// alias is held by another user
+var ErrAliasTaken = errors.New("alias is already claimed by another user")
+
+// alias to owner user ID
+var aliasCache = cache.New(1*time.Hour, 10*time.Minute)
+
+func normalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+func scanAliases(stmtSql string, args ...any) ([]*utils.Alias, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Alias, 0)
+	for rows.Next() {
+		a := new(utils.Alias)
+		if err := rows.Scan(
+			&a.Alias,
+			&a.UserID,
+			&a.ModID,
+			&a.CreatedBy,
+			&a.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, a)
+	}
+
+	return out, rows.Err()
+}
+
+// fetches the user a Geode developer name belongs to
+func GetAliasUser(alias string) (*utils.User, error) {
+	alias = normalizeAlias(alias)
+	if alias == "" {
+		return nil, fmt.Errorf("empty alias")
+	}
+
+	if val, found := aliasCache.Get(alias); found {
+		return GetUser(val.(uint64))
+	}
+
+	stmt, err := utils.PrepareStmt(dat, "SELECT user_id FROM developer_aliases WHERE alias = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var uid uint64
+	if err := stmt.QueryRow(alias).Scan(&uid); err != nil {
+		return nil, err
+	}
+
+	aliasCache.Set(alias, uid, cache.DefaultExpiration)
+
+	return GetUser(uid)
+}
+
+func GetAlias(alias string) (*utils.Alias, error) {
+	aliases, err := scanAliases("SELECT * FROM developer_aliases WHERE alias = ?", normalizeAlias(alias))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(aliases) <= 0 {
+		return nil, fmt.Errorf("alias %s not found", alias)
+	}
+
+	return aliases[0], nil
+}
+
+func ListAliases() ([]*utils.Alias, error) {
+	return scanAliases("SELECT * FROM developer_aliases ORDER BY alias")
+}
+
+func ListAliasesForUser(userId uint64) ([]*utils.Alias, error) {
+	return scanAliases("SELECT * FROM developer_aliases WHERE user_id = ? ORDER BY alias", userId)
+}
+
+// links a Geode developer name to a user, failing with ErrAliasTaken if someone else already holds it
+func CreateAlias(alias string, userId uint64, modId string, entry *utils.AuditEntry) (*utils.Alias, error) {
+	alias = normalizeAlias(alias)
+	if alias == "" || userId == 0 {
+		return nil, fmt.Errorf("missing alias fields")
+	}
+
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var owner uint64
+		err := tx.QueryRow("SELECT user_id FROM developer_aliases WHERE alias = ? FOR UPDATE", alias).Scan(&owner)
+		switch {
+		case err == nil && owner != userId:
+			return ErrAliasTaken
+		case err == nil:
+			if _, err := tx.Exec("UPDATE developer_aliases SET mod_id = ? WHERE alias = ?", modId, alias); err != nil {
+				return err
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if _, err := tx.Exec("INSERT INTO developer_aliases (alias, user_id, mod_id, created_by) VALUES (?, ?, ?, ?)", alias, userId, modId, entry.ActorID); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.Record(nil, map[string]string{"alias": alias, "mod_id": modId})
+
+		return nil
+	})
+	if err != nil {
+		// a claim racing this one may have inserted first, surfacing as a duplicate key or deadlock
+		if a, getErr := GetAlias(alias); getErr == nil && a.UserID != userId {
+			aliasCache.Set(alias, a.UserID, cache.DefaultExpiration)
+			return nil, ErrAliasTaken
+		}
+
+		return nil, err
+	}
+
+	a, err := GetAlias(alias)
+	if err != nil {
+		return nil, err
+	}
+
+	aliasCache.Set(alias, a.UserID, cache.DefaultExpiration)
+
+	return a, nil
+}
+
+func DeleteAlias(alias string, entry *utils.AuditEntry) error {
+	a, err := GetAlias(alias)
+	if err != nil {
+		return err
+	}
+
+	err = withAudit(entry, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM developer_aliases WHERE alias = ?", a.Alias); err != nil {
+			return err
+		}
+
+		entry.TargetUser = a.UserID
+		entry.Record(a, nil)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	aliasCache.Delete(a.Alias)
+
+	return nil
+}
@@ -0,0 +1,438 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"service/utils"
+)
+
+var ErrNotAppealable = errors.New("action cannot be appealed")
+
+// scans a row of SELECT * FROM appeals
+func scanAppeal(row rowScanner) (*utils.Appeal, error) {
+	a := new(utils.Appeal)
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.AuditID,
+		&a.Action,
+		&a.ImageID,
+		&a.Message,
+		&a.Status,
+		&a.ReviewerID,
+		&a.Created,
+		&a.Updated,
+	)
+
+	return a, err
+}
+
+func insertAppealEvent(tx *sql.Tx, appealId uint64, actorId uint64, status string, note string) error {
+	_, err := tx.Exec("INSERT INTO appeal_events (appeal_id, actor_id, status, note) VALUES (?, ?, ?, LEFT(?, 1024))", appealId, actorId, status, note)
+	return err
+}
+
+func listAppealEvents(appealId uint64) ([]*utils.AppealEvent, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM appeal_events WHERE appeal_id = ? ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(appealId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.AppealEvent, 0)
+	for rows.Next() {
+		e := new(utils.AppealEvent)
+		if err := rows.Scan(
+			&e.ID,
+			&e.AppealID,
+			&e.ActorID,
+			&e.Status,
+			&e.Note,
+			&e.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, e)
+	}
+
+	return out, rows.Err()
+}
+
+// fills in the history and context shown with an appeal
+func describeAppeal(a *utils.Appeal) error {
+	events, err := listAppealEvents(a.ID)
+	if err != nil {
+		return err
+	}
+
+	a.Events = events
+
+	if entry, err := GetAudit(a.AuditID); err == nil {
+		a.Reason = entry.Reason
+	}
+
+	if u, err := GetUser(a.UserID); err == nil {
+		a.Login = u.Login
+		a.Banned = u.Banned
+	}
+
+	return nil
+}
+
+func scanAppeals(stmtSql string, args ...any) ([]*utils.Appeal, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Appeal, 0)
+	for rows.Next() {
+		a, err := scanAppeal(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range out {
+		if err := describeAppeal(a); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func GetAppeal(id uint64) (*utils.Appeal, error) {
+	appeals, err := scanAppeals("SELECT * FROM appeals WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(appeals) <= 0 {
+		return nil, errNotFound("appeal", id)
+	}
+
+	return appeals[0], nil
+}
+
+func ListAppealsForUser(userId uint64) ([]*utils.Appeal, error) {
+	return scanAppeals("SELECT * FROM appeals WHERE user_id = ? ORDER BY id DESC", userId)
+}
+
+// lists appeals in a state, or every undecided appeal when status is empty, oldest first
+func ListAppeals(status string) ([]*utils.Appeal, error) {
+	if status == "" {
+		return scanAppeals("SELECT * FROM appeals WHERE status IN (?, ?) ORDER BY id", utils.AppealOpen, utils.AppealReview)
+	}
+
+	return scanAppeals("SELECT * FROM appeals WHERE status = ? ORDER BY id", status)
+}
+
+// files an appeal against a moderation action taken on the user, one per action
+func CreateAppeal(userId uint64, auditId uint64, message string, entry *utils.AuditEntry) (*utils.Appeal, error) {
+	var id uint64
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		contested, err := scanAudit(tx.QueryRow("SELECT * FROM audit_log WHERE id = ?", auditId))
+		if err == sql.ErrNoRows {
+			return errNotFound("audit entry", auditId)
+		} else if err != nil {
+			return err
+		}
+
+		// users can't appeal their own deletions or someone else's moderation
+		if contested.TargetUser != userId || contested.ActorID == userId || !slices.Contains(utils.AppealableActions, contested.Action) {
+			return ErrNotAppealable
+		}
+
+		var existing int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM appeals WHERE audit_id = ?", auditId).Scan(&existing); err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return ErrConflict
+		}
+
+		res, err := tx.Exec("INSERT INTO appeals (user_id, audit_id, action, image_id, message) VALUES (?, ?, ?, ?, LEFT(?, 2000))", userId, auditId, contested.Action, contested.TargetImage, message)
+		if err != nil {
+			return err
+		}
+
+		last, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = uint64(last)
+
+		if err := insertAppealEvent(tx, id, userId, utils.AppealOpen, ""); err != nil {
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.TargetImage = contested.TargetImage
+		entry.Record(nil, map[string]any{"appeal_id": id, "audit_id": auditId, "action": contested.Action})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAppeal(id)
+}
+
+// moves an appeal to another state, reverting the contested action when it is granted, hashing the file of an image put back
+func UpdateAppeal(id uint64, status string, note string, hash func(path string) (uint64, uint64, error), entry *utils.AuditEntry) (*utils.Appeal, error) {
+	var before *utils.Appeal
+	var restored *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var err error
+		before, err = scanAppeal(tx.QueryRow("SELECT * FROM appeals WHERE id = ? FOR UPDATE", id))
+		if err == sql.ErrNoRows {
+			return errNotFound("appeal", id)
+		} else if err != nil {
+			return err
+		}
+
+		if !utils.CanTransitionAppeal(before.Status, status) {
+			return ErrConflict
+		}
+
+		if status == utils.AppealGranted {
+			restored, err = revertAction(tx, before, hash, entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE appeals SET status = ?, reviewer_id = ? WHERE id = ?", status, entry.ActorID, id); err != nil {
+			return err
+		}
+
+		if err := insertAppealEvent(tx, id, entry.ActorID, status, note); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Status = status
+		after.ReviewerID = entry.ActorID
+
+		entry.TargetUser = before.UserID
+		entry.TargetImage = before.ImageID
+		entry.Record(before, &after)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status == utils.AppealGranted {
+		forgetBlocklist()
+
+		if before.Action == utils.AuditUserBan {
+			currentUsers = deleteUser(before.UserID)
+		}
+
+		restoring := []*utils.Img{}
+		if before.Action == utils.AuditUserBan {
+			// every image of the user was archived with the ban
+			imgs, err := scanImages("SELECT * FROM images WHERE user_id = ?", before.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			restoring = imgs
+		} else if restored != nil {
+			restoring = append(restoring, restored)
+		}
+
+		for _, img := range restoring {
+			currentImages = setImage(img)
+
+			if err := restoreImageFile(img); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	return GetAppeal(id)
+}
+
+// undoes the action an appeal contests inside its transaction, returning the image that goes back live
+func revertAction(tx *sql.Tx, appeal *utils.Appeal, hash func(path string) (uint64, uint64, error), entry *utils.AuditEntry) (*utils.Img, error) {
+	effect := utils.NewAudit(nil, "", entry.Reason)
+	effect.ActorID = entry.ActorID
+	effect.TargetUser = appeal.UserID
+	effect.TargetImage = appeal.ImageID
+
+	var img *utils.Img
+	switch appeal.Action {
+	case utils.AuditUserBan:
+		user, err := scanUser(tx.QueryRow("SELECT * FROM users WHERE id = ? FOR UPDATE", appeal.UserID))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec("UPDATE users SET banned = FALSE WHERE id = ?", appeal.UserID); err != nil {
+			return nil, err
+		}
+
+		after := *user
+		after.Banned = false
+
+		effect.Action = utils.AuditUserUnban
+		effect.Record(user, &after)
+
+		// the banned user's image row stays, only its file was archived
+		img, err = scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ?", appeal.ImageID))
+		if err == sql.ErrNoRows {
+			img = nil
+		} else if err != nil {
+			return nil, err
+		}
+
+	case utils.AuditImageDelete, utils.AuditImageReject:
+		contested, err := scanAudit(tx.QueryRow("SELECT * FROM audit_log WHERE id = ?", appeal.AuditID))
+		if err != nil {
+			return nil, err
+		}
+
+		img = new(utils.Img)
+		if err := json.Unmarshal(contested.Before, img); err != nil {
+			return nil, fmt.Errorf("failed to read removed img %d: %w", appeal.ImageID, err)
+		}
+
+		archived := archivedFile(img.ID)
+		if _, err := os.Stat(archived); err != nil {
+			return nil, fmt.Errorf("archived file of img %d is gone: %w", img.ID, err)
+		}
+
+		// hashes are left out of the audit state, so they come from the file again
+		phash, dhash, err := hash(archived)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash archived file of img %d: %w", img.ID, err)
+		}
+
+		img.PHash, img.DHash = &phash, &dhash
+
+		// one image per slot, so a newer submission has to go first
+		where, args := imageOwner(img)
+		var current int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM images WHERE "+where+" FOR UPDATE", args...).Scan(&current); err != nil {
+			return nil, err
+		}
+
+		if current > 0 {
+			return nil, ErrConflict
+		}
+
+		flags, err := json.Marshal(img.Flags)
+		if err != nil {
+			return nil, err
+		}
+
+		img.Pending = false
+		img.Version++
+
+		var starts, ends sql.NullTime
+		if img.Scheduled() {
+			starts = sql.NullTime{Time: *img.Starts, Valid: true}
+			ends = sql.NullTime{Time: *img.Ends, Valid: true}
+			img.Schedule = utils.ScheduleWaiting
+		}
+
+		userId, orgId := ownerColumns(img)
+		if _, err := tx.Exec(
+			"INSERT INTO images (id, user_id, image_url, created_at, pending, legacy, version, risk_score, flags, phash, dhash, slot, starts_at, ends_at, schedule_state, org_id, submitted_by) VALUES (?, ?, ?, ?, FALSE, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			img.ID,
+			userId,
+			img.ImageURL,
+			img.Created,
+			img.Legacy,
+			img.Version,
+			img.Risk,
+			string(flags),
+			phash,
+			dhash,
+			img.Slot,
+			starts,
+			ends,
+			img.Schedule,
+			orgId,
+			img.UserID,
+		); err != nil {
+			return nil, err
+		}
+
+		effect.Action = utils.AuditImageRestore
+		effect.Record(nil, img)
+
+	case utils.AuditImageUnpublish:
+		before, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ? FOR UPDATE", appeal.ImageID))
+		if err == sql.ErrNoRows {
+			return nil, ErrConflict
+		} else if err != nil {
+			return nil, err
+		}
+
+		// resubmitted or already back live since it was unpublished
+		if !before.Pending {
+			return nil, ErrConflict
+		}
+
+		if _, err := tx.Exec("UPDATE images SET pending = FALSE, version = version + 1 WHERE id = ?", appeal.ImageID); err != nil {
+			return nil, err
+		}
+
+		img, err = scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ?", appeal.ImageID))
+		if err != nil {
+			return nil, err
+		}
+
+		effect.Action = utils.AuditImageRestore
+		effect.Record(before, img)
+
+	default:
+		return nil, ErrNotAppealable
+	}
+
+	// blocks added along with the removal would stop the owner from using the image again
+	if appeal.ImageID != 0 {
+		if _, err := tx.Exec("DELETE FROM hash_blocklist WHERE source_image_id = ?", appeal.ImageID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertAudit(tx, effect); err != nil {
+		return nil, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return img, nil
+}
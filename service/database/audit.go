@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"service/utils"
+)
+
+// most audit entries returned by one query
+const maxAuditLimit = 1000
+
+func nullableJSON(b []byte) sql.NullString {
+	return sql.NullString{String: string(b), Valid: len(b) > 0}
+}
+
+func insertAudit(tx *sql.Tx, entry *utils.AuditEntry) error {
+	_, err := tx.Exec(
+		"INSERT INTO audit_log (actor_id, action, target_user_id, target_image_id, state_before, state_after, reason) VALUES (?, ?, ?, ?, ?, ?, LEFT(?, 1024))",
+		entry.ActorID,
+		entry.Action,
+		entry.TargetUser,
+		entry.TargetImage,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+		entry.Reason,
+	)
+
+	return err
+}
+
+// runs fn in a transaction and records the audit entry in it, so the change is never kept without its entry
+func withAudit(entry *utils.AuditEntry, fn func(tx *sql.Tx) error) error {
+	if entry == nil {
+		return fmt.Errorf("missing audit entry")
+	}
+
+	if dat == nil {
+		return fmt.Errorf("database connection non-existent")
+	}
+
+	tx, err := dat.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := insertAudit(tx, entry); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// records an entry for an action that changed nothing else, like an automatic rejection
+func RecordAudit(entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error { return nil })
+}
+
+// scans a row of SELECT * FROM audit_log
+func scanAudit(row rowScanner) (*utils.AuditEntry, error) {
+	e := new(utils.AuditEntry)
+	var before, after sql.NullString
+	if err := row.Scan(
+		&e.ID,
+		&e.ActorID,
+		&e.Action,
+		&e.TargetUser,
+		&e.TargetImage,
+		&before,
+		&after,
+		&e.Reason,
+		&e.Created,
+	); err != nil {
+		return nil, err
+	}
+
+	if before.Valid {
+		e.Before = []byte(before.String)
+	}
+
+	if after.Valid {
+		e.After = []byte(after.String)
+	}
+
+	return e, nil
+}
+
+func GetAudit(id uint64) (*utils.AuditEntry, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM audit_log WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	return scanAudit(stmt.QueryRow(id))
+}
+
+func ListAudit(filter utils.AuditFilter) ([]*utils.AuditEntry, error) {
+	var where []string
+	var args []any
+
+	if filter.ActorID != 0 {
+		where = append(where, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.TargetUser != 0 {
+		where = append(where, "target_user_id = ?")
+		args = append(args, filter.TargetUser)
+	}
+
+	if filter.TargetImage != 0 {
+		where = append(where, "target_image_id = ?")
+		args = append(args, filter.TargetImage)
+	}
+
+	if !filter.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+
+	if !filter.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.Until)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	stmtSql := "SELECT * FROM audit_log"
+	if len(where) > 0 {
+		stmtSql += " WHERE " + strings.Join(where, " AND ")
+	}
+	stmtSql += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.AuditEntry, 0)
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, e)
+	}
+
+	return out, rows.Err()
+}
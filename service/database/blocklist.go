@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+
+	"service/utils"
+)
+
+// blocklist is read on every submission, so it is kept in memory between changes
+var (
+	blocklistMu      sync.Mutex
+	currentBlocklist []*utils.BlockedHash
+)
+
+func ListBlockedHashes() ([]*utils.BlockedHash, error) {
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+
+	if currentBlocklist != nil {
+		return currentBlocklist, nil
+	}
+
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM hash_blocklist ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.BlockedHash, 0)
+	for rows.Next() {
+		b := new(utils.BlockedHash)
+		if err := rows.Scan(
+			&b.ID,
+			&b.PHash,
+			&b.DHash,
+			&b.Reason,
+			&b.SourceImage,
+			&b.CreatedBy,
+			&b.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	currentBlocklist = out
+
+	return out, nil
+}
+
+func forgetBlocklist() {
+	blocklistMu.Lock()
+	currentBlocklist = nil
+	blocklistMu.Unlock()
+}
+
+// blocks an image's hashes, sourceImage is 0 when they were not taken from a stored image
+func AddBlockedHash(phash uint64, dhash uint64, reason string, sourceImage uint64, entry *utils.AuditEntry) (uint64, error) {
+	var id uint64
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT INTO hash_blocklist (phash, dhash, reason, source_image_id, created_by) VALUES (?, ?, LEFT(?, 1024), ?, ?)", phash, dhash, reason, sourceImage, entry.ActorID)
+		if err != nil {
+			return err
+		}
+
+		last, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = uint64(last)
+
+		entry.TargetImage = sourceImage
+		entry.Record(nil, &utils.BlockedHash{ID: id, PHash: phash, DHash: dhash, Reason: reason, SourceImage: sourceImage, CreatedBy: entry.ActorID})
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	forgetBlocklist()
+
+	return id, nil
+}
+
+func DeleteBlockedHash(id uint64, entry *utils.AuditEntry) error {
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		b := new(utils.BlockedHash)
+		err := tx.QueryRow("SELECT * FROM hash_blocklist WHERE id = ? FOR UPDATE", id).Scan(
+			&b.ID,
+			&b.PHash,
+			&b.DHash,
+			&b.Reason,
+			&b.SourceImage,
+			&b.CreatedBy,
+			&b.Created,
+		)
+		if err == sql.ErrNoRows {
+			return errNotFound("blocked hash", id)
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM hash_blocklist WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		entry.TargetImage = b.SourceImage
+		entry.Record(b, nil)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	forgetBlocklist()
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"service/log"
+	"service/utils"
+)
+
+// applies a moderation action to many images in one transaction, each image in its own savepoint so one failure doesn't undo the rest
+func BulkModerate(action string, ids []uint64, actor *utils.User, auditAction string, reason string) ([]*utils.BulkResult, error) {
+	var apply func(tx *sql.Tx, id uint64, entry *utils.AuditEntry) (*utils.Img, error)
+	switch action {
+	case utils.BulkApprove:
+		apply = func(tx *sql.Tx, id uint64, entry *utils.AuditEntry) (*utils.Img, error) {
+			// never skips the quorum, overrides are made one image at a time
+			return approveImage(tx, id, 0, false, entry)
+		}
+	case utils.BulkReject, utils.BulkDelete:
+		apply = func(tx *sql.Tx, id uint64, entry *utils.AuditEntry) (*utils.Img, error) {
+			// the queue is only ever pending images, anything published since is left alone
+			return removeImage(tx, id, 0, true, entry)
+		}
+	default:
+		return nil, fmt.Errorf("unknown bulk action %s", action)
+	}
+
+	if dat == nil {
+		return nil, fmt.Errorf("database connection non-existent")
+	}
+
+	tx, err := dat.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]*utils.BulkResult, 0, len(ids))
+	seen := make(map[uint64]bool)
+	for _, id := range ids {
+		res := &utils.BulkResult{ImageID: id}
+		results = append(results, res)
+
+		if seen[id] {
+			res.Error = "duplicate image ID"
+			continue
+		}
+		seen[id] = true
+
+		if _, err := tx.Exec("SAVEPOINT bulk_item"); err != nil {
+			return nil, err
+		}
+
+		entry := utils.NewAudit(actor, auditAction, reason)
+		img, err := apply(tx, id, entry)
+		if err == nil {
+			err = insertAudit(tx, entry)
+		}
+
+		if err != nil {
+			if _, e := tx.Exec("ROLLBACK TO SAVEPOINT bulk_item"); e != nil {
+				return nil, e
+			}
+
+			if err == sql.ErrNoRows {
+				err = errNotFound("img", id)
+			}
+
+			res.Error = err.Error()
+			continue
+		}
+
+		if _, err := tx.Exec("RELEASE SAVEPOINT bulk_item"); err != nil {
+			return nil, err
+		}
+
+		res.OK = true
+		res.Image = img
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, res := range results {
+		if !res.OK {
+			continue
+		}
+
+		if action == utils.BulkApprove {
+			if !res.Image.Pending {
+				cacheApproved(res.Image)
+			}
+		} else if err := dropImageFile(res.Image, actor.ID); err != nil {
+			log.Warn("Failed to take down file of img %d: %s", res.ImageID, err.Error())
+		}
+	}
+
+	return results, nil
+}
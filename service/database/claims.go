@@ -0,0 +1,174 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"service/utils"
+)
+
+// decision was made on a changed submission, or one another staff member is reviewing
+var ErrConflict = errors.New("submission was changed or is being reviewed by someone else")
+
+func scanClaims(stmtSql string, args ...any) ([]*utils.ImageClaim, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.ImageClaim, 0)
+	for rows.Next() {
+		c := new(utils.ImageClaim)
+		if err := rows.Scan(
+			&c.ImageID,
+			&c.StaffID,
+			&c.StaffLogin,
+			&c.Claimed,
+			&c.Expires,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, c)
+	}
+
+	return out, rows.Err()
+}
+
+const claimColumns = "SELECT c.image_id, c.staff_id, u.login, c.claimed_at, c.expires_at FROM image_claims c JOIN users u ON u.id = c.staff_id"
+
+// unexpired claims keyed by image ID
+func ListClaims() (map[uint64]*utils.ImageClaim, error) {
+	claims, err := scanClaims(claimColumns + " WHERE c.expires_at > NOW()")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[uint64]*utils.ImageClaim, len(claims))
+	for _, c := range claims {
+		out[c.ImageID] = c
+	}
+
+	return out, nil
+}
+
+func GetClaim(imgId uint64) (*utils.ImageClaim, error) {
+	claims, err := scanClaims(claimColumns+" WHERE c.image_id = ? AND c.expires_at > NOW()", imgId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(claims) <= 0 {
+		return nil, errNotFound("claim on img", imgId)
+	}
+
+	return claims[0], nil
+}
+
+// staff member holding an unexpired claim on an image, 0 if nobody does
+func claimHolder(tx *sql.Tx, imgId uint64) (uint64, error) {
+	var staffId uint64
+	err := tx.QueryRow("SELECT staff_id FROM image_claims WHERE image_id = ? AND expires_at > NOW() FOR UPDATE", imgId).Scan(&staffId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return staffId, err
+}
+
+// refuses a decision made on an outdated version, or on an image someone else has claimed, version 0 skips the version check
+func checkDecision(tx *sql.Tx, img *utils.Img, version uint64, actorId uint64) error {
+	if version != 0 && img.Version != version {
+		return ErrConflict
+	}
+
+	holder, err := claimHolder(tx, img.ID)
+	if err != nil {
+		return err
+	}
+
+	// owners can always withdraw their own submission
+	if holder != 0 && holder != actorId && actorId != img.UserID {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// claims a pending image for review or renews the lease, returning the current claim with ErrConflict if someone else holds it
+func ClaimImage(imgId uint64, staffId uint64, lease time.Duration) (*utils.ImageClaim, error) {
+	if dat == nil {
+		return nil, errors.New("database connection non-existent")
+	}
+
+	tx, err := dat.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var pending bool
+	if err := tx.QueryRow("SELECT pending FROM images WHERE id = ? FOR UPDATE", imgId).Scan(&pending); err != nil {
+		return nil, err
+	}
+
+	if !pending {
+		return nil, ErrConflict
+	}
+
+	holder, err := claimHolder(tx, imgId)
+	if err != nil {
+		return nil, err
+	}
+
+	if holder != 0 && holder != staffId {
+		tx.Rollback()
+
+		claim, err := GetClaim(imgId)
+		if err != nil {
+			return nil, ErrConflict
+		}
+
+		return claim, ErrConflict
+	}
+
+	if _, err := tx.Exec("REPLACE INTO image_claims (image_id, staff_id, claimed_at, expires_at) VALUES (?, ?, NOW(), NOW() + INTERVAL ? SECOND)", imgId, staffId, int64(lease.Seconds())); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetClaim(imgId)
+}
+
+// drops a claim, only the holder's unless force is set
+func ReleaseClaim(imgId uint64, staffId uint64, force bool) error {
+	stmt, err := utils.PrepareStmt(dat, "DELETE FROM image_claims WHERE image_id = ? AND (staff_id = ? OR ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(imgId, staffId, force)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n <= 0 {
+		return errNotFound("claim on img", imgId)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"service/utils"
+)
+
+// fetches the user a Discord account is linked to
+func GetDiscordUser(discordId string) (*utils.User, error) {
+	if discordId == "" {
+		return nil, fmt.Errorf("empty discord id")
+	}
+
+	stmt, err := utils.PrepareStmt(dat, "SELECT user_id FROM discord_accounts WHERE discord_id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var uid uint64
+	if err := stmt.QueryRow(discordId).Scan(&uid); err != nil {
+		return nil, err
+	}
+
+	return GetUser(uid)
+}
+
+// links a Discord account to a user, replacing any account they had linked before
+func LinkDiscordUser(discordId string, userId uint64, entry *utils.AuditEntry) error {
+	if discordId == "" || userId == 0 {
+		return fmt.Errorf("missing discord link fields")
+	}
+
+	return withAudit(entry, func(tx *sql.Tx) error {
+		before, err := linkedDiscordId(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("REPLACE INTO discord_accounts (discord_id, user_id) VALUES (?, ?)", discordId, userId); err != nil {
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.Record(before, map[string]string{"discord_id": discordId})
+
+		return nil
+	})
+}
+
+func UnlinkDiscordUser(userId uint64, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		before, err := linkedDiscordId(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM discord_accounts WHERE user_id = ?", userId); err != nil {
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.Record(before, nil)
+
+		return nil
+	})
+}
+
+// Discord account linked to a user as audit state, nil if there is none
+func linkedDiscordId(tx *sql.Tx, userId uint64) (map[string]string, error) {
+	var discordId string
+	err := tx.QueryRow("SELECT discord_id FROM discord_accounts WHERE user_id = ? FOR UPDATE", userId).Scan(&discordId)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"discord_id": discordId}, nil
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"slices"
+
+	"service/utils"
+)
+
+// audit actions that change what a user may do
+var roleActions = []string{
+	utils.AuditUserVerify,
+	utils.AuditUserBan,
+	utils.AuditUserUnban,
+}
+
+// audit actions that decide what happens to a submission
+var decisionActions = []string{
+	utils.AuditImageApprove,
+	utils.AuditImageOverride,
+	utils.AuditImageReject,
+	utils.AuditImageDelete,
+	utils.AuditImageUnpublish,
+	utils.AuditImageRestore,
+}
+
+// reports against any image a user still has, in every state
+func ListReportsForUser(userId uint64) ([]*utils.Report, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT r.id, r.image_id, r.category, r.note, r.status, r.created_at FROM reports r JOIN images i ON i.id = r.image_id WHERE i.user_id = ? ORDER BY r.id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Report, 0)
+	for rows.Next() {
+		r := new(utils.Report)
+		if err := rows.Scan(
+			&r.ID,
+			&r.ImageID,
+			&r.Category,
+			&r.Note,
+			&r.Status,
+			&r.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+// replays a user's audit trail oldest first into their submissions and what became of each
+func submissionHistory(entries []*utils.AuditEntry) []*utils.SubmissionRecord {
+	out := make([]*utils.SubmissionRecord, 0)
+	byImage := make(map[uint64]*utils.SubmissionRecord)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+
+		switch {
+		case e.Action == utils.AuditImageScreened:
+			at := e.Created
+			out = append(out, &utils.SubmissionRecord{Submitted: e.Created, Outcome: e.Action, Decided: &at, Reason: e.Reason})
+
+		case e.Action == utils.AuditImageSubmit:
+			rec := &utils.SubmissionRecord{ImageID: e.TargetImage, Submitted: e.Created}
+			byImage[e.TargetImage] = rec
+			out = append(out, rec)
+
+		case slices.Contains(decisionActions, e.Action) && e.TargetImage != 0:
+			rec, found := byImage[e.TargetImage]
+			if !found {
+				// submitted before the audit log existed, or imported
+				rec = &utils.SubmissionRecord{ImageID: e.TargetImage, Submitted: e.Created}
+				byImage[e.TargetImage] = rec
+				out = append(out, rec)
+			}
+
+			at := e.Created
+			rec.Outcome = e.Action
+			rec.Decided = &at
+			rec.DecidedBy = e.ActorID
+			rec.Reason = e.Reason
+		}
+	}
+
+	slices.Reverse(out)
+
+	return out
+}
+
+// gathers a user's notes, submissions, reports, role changes and appeals for staff
+func GetDossier(userId uint64) (*utils.Dossier, error) {
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := ListNotesForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ListAudit(utils.AuditFilter{TargetUser: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := ListReportsForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	appeals, err := ListAppealsForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*utils.AuditEntry, 0)
+	for _, e := range entries {
+		if slices.Contains(roleActions, e.Action) {
+			roles = append(roles, e)
+		}
+	}
+
+	return &utils.Dossier{
+		User:        user,
+		Notes:       notes,
+		Submissions: submissionHistory(entries),
+		Reports:     reports,
+		RoleChanges: roles,
+		Appeals:     appeals,
+	}, nil
+}
+
+// counts from a user's dossier, cheap enough to attach to every pending image
+func GetDossierSummary(userId uint64) (*utils.DossierSummary, error) {
+	notes, err := ListNotesForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ListAudit(utils.AuditFilter{TargetUser: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := ListReportsForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &utils.DossierSummary{Notes: len(notes), Reports: len(reports)}
+	if len(notes) > 0 {
+		out.LatestNote = notes[0].Note
+	}
+
+	for _, rec := range submissionHistory(entries) {
+		out.Submissions++
+
+		switch rec.Outcome {
+		case utils.AuditImageScreened, utils.AuditImageReject:
+			out.Rejected++
+		case utils.AuditImageUnpublish:
+			out.Removed++
+		case utils.AuditImageDelete:
+			// owners deleting their own image isn't held against them
+			if rec.DecidedBy != userId {
+				out.Removed++
+			}
+		}
+	}
+
+	return out, nil
+}
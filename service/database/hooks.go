@@ -0,0 +1,222 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"service/utils"
+)
+
+func scanSubscriptions(stmtSql string, args ...any) ([]*utils.WebhookSubscription, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.WebhookSubscription, 0)
+	for rows.Next() {
+		s := new(utils.WebhookSubscription)
+		if err := rows.Scan(
+			&s.ID,
+			&s.URL,
+			&s.Secret,
+			&s.Events,
+			&s.Active,
+			&s.CreatedBy,
+			&s.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, s)
+	}
+
+	return out, rows.Err()
+}
+
+func scanDeliveries(stmtSql string, args ...any) ([]*utils.WebhookDelivery, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.WebhookDelivery, 0)
+	for rows.Next() {
+		d := new(utils.WebhookDelivery)
+		if err := rows.Scan(
+			&d.ID,
+			&d.SubscriptionID,
+			&d.Event,
+			&d.Payload,
+			&d.Status,
+			&d.Attempts,
+			&d.ResponseCode,
+			&d.LastError,
+			&d.NextAttempt,
+			&d.Created,
+			&d.Delivered,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, d)
+	}
+
+	return out, rows.Err()
+}
+
+func ListSubscriptions() ([]*utils.WebhookSubscription, error) {
+	return scanSubscriptions("SELECT * FROM webhook_subscriptions ORDER BY id")
+}
+
+func GetSubscription(id uint64) (*utils.WebhookSubscription, error) {
+	subs, err := scanSubscriptions("SELECT * FROM webhook_subscriptions WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subs) <= 0 {
+		return nil, errNotFound("webhook subscription", id)
+	}
+
+	return subs[0], nil
+}
+
+func CreateSubscription(url string, secret string, events string, entry *utils.AuditEntry) (*utils.WebhookSubscription, error) {
+	var id uint64
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT INTO webhook_subscriptions (url, secret, events, created_by) VALUES (?, ?, ?, ?)", url, secret, events, entry.ActorID)
+		if err != nil {
+			return err
+		}
+
+		last, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = uint64(last)
+
+		// the secret is left out of the log
+		entry.Record(nil, &utils.WebhookSubscription{ID: id, URL: url, Events: events, Active: true, CreatedBy: entry.ActorID})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSubscription(id)
+}
+
+func SetSubscriptionActive(id uint64, active bool, entry *utils.AuditEntry) error {
+	sub, err := GetSubscription(id)
+	if err != nil {
+		return err
+	}
+
+	sub.Secret = ""
+
+	return withAudit(entry, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE webhook_subscriptions SET active = ? WHERE id = ?", active, id); err != nil {
+			return err
+		}
+
+		after := *sub
+		after.Active = active
+		entry.Record(sub, &after)
+
+		return nil
+	})
+}
+
+func DeleteSubscription(id uint64, entry *utils.AuditEntry) error {
+	sub, err := GetSubscription(id)
+	if err != nil {
+		return err
+	}
+
+	sub.Secret = ""
+
+	return withAudit(entry, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM webhook_subscriptions WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		entry.Record(sub, nil)
+
+		return nil
+	})
+}
+
+// queues an event for delivery to a subscription
+func EnqueueDelivery(subscriptionId uint64, event string, payload []byte) (uint64, error) {
+	stmt, err := utils.PrepareStmt(dat, "INSERT INTO webhook_deliveries (subscription_id, event, payload) VALUES (?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(subscriptionId, event, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := res.LastInsertId()
+	return uint64(last), err
+}
+
+func ListDueDeliveries(limit int) ([]*utils.WebhookDelivery, error) {
+	return scanDeliveries("SELECT * FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= NOW() ORDER BY id LIMIT ?", utils.OutboxPending, limit)
+}
+
+func ListDeliveries(subscriptionId uint64) ([]*utils.WebhookDelivery, error) {
+	return scanDeliveries("SELECT * FROM webhook_deliveries WHERE subscription_id = ? ORDER BY id DESC LIMIT 200", subscriptionId)
+}
+
+func MarkDeliverySent(id uint64, code int) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE webhook_deliveries SET status = ?, response_code = ?, delivered_at = NOW() WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(utils.OutboxSent, code, id)
+	return err
+}
+
+func MarkDeliveryRetry(id uint64, code int, next time.Time, reason string) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE webhook_deliveries SET attempts = attempts + 1, response_code = ?, next_attempt_at = ?, last_error = LEFT(?, 1024) WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(code, next.UTC(), reason, id)
+	return err
+}
+
+func MarkDeliveryDead(id uint64, code int, reason string) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE webhook_deliveries SET status = ?, attempts = attempts + 1, response_code = ?, last_error = LEFT(?, 1024) WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(utils.OutboxDead, code, reason, id)
+	return err
+}
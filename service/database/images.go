@@ -4,17 +4,128 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
 	"service/log"
 	"service/utils"
-
-	"github.com/patrickmn/go-cache"
 )
 
+// image files removed by staff or bans, kept out of the public CDN folder until an appeal restores them
+var archiveDir = filepath.Join("..", "archive")
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scans a row of SELECT * FROM images
+func scanImage(row rowScanner) (*utils.Img, error) {
+	r := new(utils.Img)
+	var userId, orgId, submitter sql.Null[uint64]
+	var flags sql.NullString
+	var starts, ends sql.NullTime
+	err := row.Scan(
+		&r.ID,
+		&userId,
+		&r.ImageURL,
+		&r.Created,
+		&r.Pending,
+		&r.Legacy,
+		&r.Version,
+		&r.Risk,
+		&flags,
+		&r.PHash,
+		&r.DHash,
+		&r.Slot,
+		&starts,
+		&ends,
+		&r.Schedule,
+		&orgId,
+		&submitter,
+	)
+	if err != nil {
+		return r, err
+	}
+
+	// organization brandings belong to no user, their submitter stands in for review
+	r.UserID = userId.V
+	if !userId.Valid {
+		r.UserID = submitter.V
+	}
+
+	if starts.Valid && ends.Valid {
+		r.Starts = &starts.Time
+		r.Ends = &ends.Time
+	}
+
+	if flags.Valid && flags.String != "" {
+		if err := json.Unmarshal([]byte(flags.String), &r.Flags); err != nil {
+			log.Warn("Failed to decode screening flags of img %d: %s", r.ID, err.Error())
+		}
+	}
+
+	return r, nil
+}
+
+func scanImages(stmtSql string, args ...any) ([]*utils.Img, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Img, 0)
+	for rows.Next() {
+		r, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+// condition and arguments locating the row an image lives in, an organization has a single branding
+func imageOwner(img *utils.Img) (string, []any) {
+	if orgId := img.OrgID(); orgId != 0 {
+		return "org_id = ?", []any{orgId}
+	}
+
+	return "user_id = ? AND slot = ?", []any{img.UserID, img.Slot}
+}
+
+// owning user and organization columns of an image, only one of them is set
+func ownerColumns(img *utils.Img) (sql.Null[uint64], sql.Null[uint64]) {
+	if orgId := img.OrgID(); orgId != 0 {
+		return sql.Null[uint64]{}, sql.Null[uint64]{V: orgId, Valid: true}
+	}
+
+	return sql.Null[uint64]{V: img.UserID, Valid: true}, sql.Null[uint64]{}
+}
+
+// screening columns to store for a submission
+func screeningColumns(screening *utils.Screening) (int, sql.NullString) {
+	if screening == nil || len(screening.Flags) <= 0 {
+		return 0, sql.NullString{}
+	}
+
+	flags, err := json.Marshal(screening.Flags)
+	if err != nil {
+		return screening.Score, sql.NullString{}
+	}
+
+	return screening.Score, sql.NullString{String: string(flags), Valid: true}
+}
+
 func newImages() *[]*utils.Img {
 	return new([]*utils.Img)
 }
@@ -46,10 +157,10 @@ func findImage(id uint64) (*utils.Img, bool) {
 	return nil, false
 }
 
-func findImageFromUser(id uint64) (*utils.Img, bool) {
+func findImageFromUser(id uint64, slot string) (*utils.Img, bool) {
 	if currentImages != nil {
 		for _, img := range *currentImages {
-			if img.UserID == id {
+			if img.UserID == id && img.Slot == slot {
 				return img, true
 			}
 		}
@@ -86,51 +197,230 @@ func deleteImage(id uint64) *[]*utils.Img {
 	return getImages()
 }
 
-func ApproveImage(id uint64) (*utils.Img, error) {
-	stmt, err := utils.PrepareStmt(dat, "UPDATE images SET pending = FALSE, created_at = NOW() WHERE id = ?")
+// approves a pending image, publishing it once it has the approvals its owner needs unless override is set
+func ApproveImage(id uint64, version uint64, override bool, entry *utils.AuditEntry) (*utils.Img, error) {
+	var img *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var err error
+		img, err = approveImage(tx, id, version, override, entry)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	// only a vote was recorded
+	if img.Pending {
+		return img, nil
+	}
+
+	cacheApproved(img)
+
+	return GetImage(id)
+}
+
+// publishes a pending image inside a transaction, filling in the audit entry
+func approveImage(tx *sql.Tx, id uint64, version uint64, override bool, entry *utils.AuditEntry) (*utils.Img, error) {
+	before, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ? FOR UPDATE", id))
 	if err != nil {
 		return nil, err
 	}
 
-	if img, found := findImage(id); found {
-		img.Pending = false
-		currentImages = setImage(img)
+	if !before.Pending {
+		return nil, ErrConflict
 	}
 
-	return GetImage(id)
+	if before.Scheduled() && !time.Now().Before(*before.Ends) {
+		return nil, ErrWindowOver
+	}
+
+	if err := checkDecision(tx, before, version, entry.ActorID); err != nil {
+		return nil, err
+	}
+
+	// automatic approvals have no actor to vote with
+	if !override && entry.ActorID != 0 {
+		owner, err := scanUser(tx.QueryRow("SELECT * FROM users WHERE id = ?", before.UserID))
+		if err != nil {
+			return nil, err
+		}
+
+		if quorum := requiredApprovals(owner); quorum > 1 {
+			votes, err := castVote(tx, before, entry.ActorID)
+			if err != nil {
+				return nil, err
+			}
+
+			if votes < quorum {
+				// the voter steps aside so the next reviewer can claim it
+				if _, err := tx.Exec("DELETE FROM image_claims WHERE image_id = ? AND staff_id = ?", id, entry.ActorID); err != nil {
+					return nil, err
+				}
+
+				before.Approvals = &utils.ApprovalTally{Votes: votes, Quorum: quorum}
+
+				entry.Action = utils.AuditImageVote
+				entry.TargetUser = before.UserID
+				entry.TargetImage = before.ID
+				entry.Record(nil, before.Approvals)
+
+				return before, nil
+			}
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE images SET pending = FALSE, version = version + 1, created_at = NOW() WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM image_claims WHERE image_id = ?", id); err != nil {
+		return nil, err
+	}
+
+	img, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	entry.TargetUser = img.UserID
+	entry.TargetImage = img.ID
+	entry.Record(before, img)
+
+	return img, nil
 }
 
-// upserts a brand image row
-func CreateImage(userId uint64, url string) (uint64, error) {
-	if userId == 0 {
+// updates the cached copy of an image once its approval is committed
+func cacheApproved(img *utils.Img) {
+	if cached, found := findImage(img.ID); found {
+		cached.Pending = false
+		cached.Version = img.Version
+		cached.Created = img.Created
+		currentImages = setImage(cached)
+	}
+}
+
+// upserts the brand image row in the draft's slot
+func CreateImage(draft *utils.Img, screening *utils.Screening, entry *utils.AuditEntry) (uint64, error) {
+	if draft.UserID == 0 {
 		return 0, fmt.Errorf("missing img fields")
 	}
 
-	// Create new img - allow multiple imgs per user per type
-	stmt, err := utils.PrepareStmt(dat, "INSERT INTO images (user_id, image_url, pending) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE image_url = VALUES(image_url), pending = VALUES(pending), created_at = CURRENT_TIMESTAMP")
+	var starts, ends sql.NullTime
+	schedule := ""
+	if draft.Scheduled() {
+		starts = sql.NullTime{Time: *draft.Starts, Valid: true}
+		ends = sql.NullTime{Time: *draft.Ends, Valid: true}
+		schedule = utils.ScheduleWaiting
+	}
+
+	var img, replaced *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		if orgId := draft.OrgID(); orgId != 0 {
+			var locked uint64
+			if err := tx.QueryRow("SELECT id FROM organizations WHERE id = ? FOR UPDATE", orgId).Scan(&locked); err != nil {
+				return err
+			}
+		}
+
+		where, args := imageOwner(draft)
+		before, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE "+where+" FOR UPDATE", args...))
+		if err == sql.ErrNoRows {
+			before = nil
+		} else if err != nil {
+			return err
+		}
+
+		risk, flags := screeningColumns(screening)
+
+		var phash, dhash *uint64
+		if screening != nil {
+			phash, dhash = screening.PHash, screening.DHash
+		}
+
+		userId, orgId := ownerColumns(draft)
+		if _, err := tx.Exec(
+			"INSERT INTO images (user_id, image_url, pending, risk_score, flags, phash, dhash, slot, starts_at, ends_at, schedule_state, org_id, submitted_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE image_url = VALUES(image_url), pending = VALUES(pending), legacy = FALSE, version = version + 1, risk_score = VALUES(risk_score), flags = VALUES(flags), phash = VALUES(phash), dhash = VALUES(dhash), starts_at = VALUES(starts_at), ends_at = VALUES(ends_at), schedule_state = VALUES(schedule_state), submitted_by = VALUES(submitted_by), created_at = CURRENT_TIMESTAMP",
+			userId, draft.ImageURL, true, risk, flags, phash, dhash, draft.Slot, starts, ends, schedule, orgId, draft.UserID,
+		); err != nil {
+			return err
+		}
+
+		// a new upload needs a fresh review
+		if before != nil {
+			if _, err := tx.Exec("DELETE FROM image_claims WHERE image_id = ?", before.ID); err != nil {
+				return err
+			}
+		}
+
+		img, err = scanImage(tx.QueryRow("SELECT * FROM images WHERE "+where, args...))
+		if err != nil {
+			return err
+		}
+
+		replaced = before
+
+		entry.TargetUser = draft.UserID
+		entry.TargetImage = img.ID
+		entry.Record(before, img)
+
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
 
-	res, err := stmt.Exec(userId, url, true)
+	// the cached row may still name the previous submitter
+	currentImages = deleteImage(img.ID)
+	currentImages = setImage(img)
+
+	// a legacy PNG replaced by an upload would otherwise stay reachable on the CDN
+	if replaced != nil && replaced.FileName() != img.FileName() {
+		if err := os.Remove(filepath.Join("..", "cdn", replaced.FileName())); err != nil && !os.IsNotExist(err) {
+			log.Warn("Failed to remove replaced file of img %d: %s", img.ID, err.Error())
+		}
+	}
+
+	return img.ID, nil
+}
+
+// inserts an approved brand image imported from the legacy images repository, leaving existing brandings untouched, the row is only committed once store has put its file in place
+func ImportLegacyImage(userId uint64, url string, store func() error) (*utils.Img, bool, error) {
+	if userId == 0 {
+		return nil, false, fmt.Errorf("missing img fields")
+	}
+
+	if dat == nil {
+		return nil, false, fmt.Errorf("database connection non-existent")
+	}
+
+	tx, err := dat.Begin()
 	if err != nil {
-		return 0, err
+		return nil, false, err
 	}
+	defer tx.Rollback()
 
-	if img, found := findImageFromUser(userId); found {
-		img.ImageURL = url
-		currentImages = setImage(img)
+	res, err := tx.Exec("INSERT IGNORE INTO images (user_id, image_url, pending, legacy) VALUES (?, ?, FALSE, TRUE)", userId, url)
+	if err != nil {
+		return nil, false, err
 	}
 
-	last, err := res.LastInsertId()
-	return uint64(last), err
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if inserted > 0 {
+		if err := store(); err != nil {
+			return nil, false, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	img, err := GetImageForUser(userId)
+	return img, inserted > 0, err
 }
 
 // fetches all imgs for a given user
@@ -158,14 +448,8 @@ func ListAllImages() ([]*utils.Img, error) {
 
 	var out []*utils.Img
 	for rows.Next() {
-		r := new(utils.Img)
-		if err := rows.Scan(
-			&r.ID,
-			&r.UserID,
-			&r.ImageURL,
-			&r.Created,
-			&r.Pending,
-		); err != nil {
+		r, err := scanImage(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -192,14 +476,8 @@ func ListPendingImages() ([]*utils.Img, error) {
 
 	out := make([]*utils.Img, 0)
 	for rows.Next() {
-		r := new(utils.Img)
-		if err := rows.Scan(
-			&r.ID,
-			&r.UserID,
-			&r.ImageURL,
-			&r.Created,
-			&r.Pending,
-		); err != nil {
+		r, err := scanImage(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -225,6 +503,12 @@ func FilterImagesByPending(rows []*utils.Img, showPending bool) ([]*utils.Img, e
 func FilterImagesFromBannedUsers(rows []*utils.Img) ([]*utils.Img, error) {
 	out := make([]*utils.Img, 0)
 	for _, r := range rows {
+		// an organization's branding isn't taken down with whoever submitted it
+		if r.OrgID() != 0 {
+			out = append(out, r)
+			continue
+		}
+
 		user, err := GetUser(r.UserID)
 		if err != nil {
 			return nil, err
@@ -241,7 +525,8 @@ func FilterImagesFromBannedUsers(rows []*utils.Img) ([]*utils.Img, error) {
 func FilterImagesByUser(rows []*utils.Img, userId uint64) ([]*utils.Img, error) {
 	out := make([]*utils.Img, 0)
 	for _, r := range rows {
-		if r.UserID == userId {
+		// an organization's branding is not its submitter's to manage
+		if r.UserID == userId && r.OrgID() == 0 {
 			out = append(out, r)
 		}
 	}
@@ -249,6 +534,52 @@ func FilterImagesByUser(rows []*utils.Img, userId uint64) ([]*utils.Img, error)
 	return out, nil
 }
 
+// images stored before perceptual hashes were computed
+func ListUnhashedImages() ([]*utils.Img, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM images WHERE phash IS NULL OR dhash IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Img, 0)
+	for rows.Next() {
+		r, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+func SetImageHashes(imgId uint64, phash uint64, dhash uint64) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE images SET phash = ?, dhash = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(phash, dhash, imgId); err != nil {
+		return err
+	}
+
+	if cached, found := findImage(imgId); found {
+		cached.PHash = &phash
+		cached.DHash = &dhash
+	}
+
+	return nil
+}
+
 func GetImage(imgId uint64) (*utils.Img, error) {
 	if val, found := findImage(imgId); found {
 		return val, nil
@@ -262,14 +593,8 @@ func GetImage(imgId uint64) (*utils.Img, error) {
 
 	row := stmt.QueryRow(imgId)
 	if row != nil {
-		r := new(utils.Img)
-		if err := row.Scan(
-			&r.ID,
-			&r.UserID,
-			&r.ImageURL,
-			&r.Created,
-			&r.Pending,
-		); err != nil {
+		r, err := scanImage(row)
+		if err != nil {
 			if err == sql.ErrNoRows {
 				return nil, err
 			}
@@ -285,27 +610,26 @@ func GetImage(imgId uint64) (*utils.Img, error) {
 	}
 }
 
+// fetches a user's default branding
 func GetImageForUser(userId uint64) (*utils.Img, error) {
-	if val, found := findImageFromUser(userId); found {
+	return GetImageForSlot(userId, utils.SlotDefault)
+}
+
+func GetImageForSlot(userId uint64, slot string) (*utils.Img, error) {
+	if val, found := findImageFromUser(userId, slot); found {
 		return val, nil
 	}
 
-	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM images WHERE user_id = ?")
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM images WHERE user_id = ? AND slot = ?")
 	if err != nil {
 		return nil, err
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(userId)
+	row := stmt.QueryRow(userId, slot)
 	if row != nil {
-		r := new(utils.Img)
-		if err := row.Scan(
-			&r.ID,
-			&r.UserID,
-			&r.ImageURL,
-			&r.Created,
-			&r.Pending,
-		); err != nil {
+		r, err := scanImage(row)
+		if err != nil {
 			if err == sql.ErrNoRows {
 				return nil, err
 			}
@@ -321,13 +645,17 @@ func GetImageForUser(userId uint64) (*utils.Img, error) {
 	}
 }
 
-// returns the owning user_id for a brand image
+// returns the owning user_id for a brand image, 0 for an organization's branding
 func GetImageOwnerId(imgId uint64) (uint64, error) {
 	if val, found := findImage(imgId); found {
+		if val.OrgID() != 0 {
+			return 0, nil
+		}
+
 		return val.UserID, nil
 	}
 
-	var uid uint64
+	var uid sql.Null[uint64]
 
 	stmt, err := utils.PrepareStmt(dat, "SELECT user_id FROM images WHERE id = ?")
 	if err != nil {
@@ -340,83 +668,104 @@ func GetImageOwnerId(imgId uint64) (uint64, error) {
 		return 0, err
 	}
 
-	return uid, nil
+	return uid.V, nil
 }
 
-func DeleteImage(imgId uint64) (*utils.Img, error) {
-	img, err := GetImage(imgId)
+func DeleteImage(imgId uint64, version uint64, entry *utils.AuditEntry) (*utils.Img, error) {
+	var img *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var err error
+		img, err = removeImage(tx, imgId, version, false, entry)
+		return err
+	})
 	if err != nil {
 		return img, err
 	}
 
-	stmt, err := utils.PrepareStmt(dat, "DELETE FROM images WHERE id = ?")
+	return img, dropImageFile(img, entry.ActorID)
+}
+
+// deletes a pending image as a rejection, published ones can only be deleted
+func RejectImage(imgId uint64, version uint64, entry *utils.AuditEntry) (*utils.Img, error) {
+	var img *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var err error
+		img, err = removeImage(tx, imgId, version, true, entry)
+		return err
+	})
 	if err != nil {
 		return img, err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(imgId)
+	return img, dropImageFile(img, entry.ActorID)
+}
+
+// deletes an image row inside a transaction, filling in the audit entry, refusing images already published when pendingOnly is set
+func removeImage(tx *sql.Tx, imgId uint64, version uint64, pendingOnly bool, entry *utils.AuditEntry) (*utils.Img, error) {
+	img, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ? FOR UPDATE", imgId))
 	if err != nil {
+		return nil, err
+	}
+
+	if pendingOnly && !img.Pending {
+		return img, ErrConflict
+	}
+
+	if err := checkDecision(tx, img, version, entry.ActorID); err != nil {
 		return img, err
 	}
 
-	adDir := filepath.Join("..", "cdn", fmt.Sprintf("%d.webp", img.UserID))
-	err = os.Remove(adDir)
-	if err != nil {
+	if _, err := tx.Exec("DELETE FROM images WHERE id = ?", imgId); err != nil {
 		return img, err
 	}
 
-	currentImages = deleteImage(imgId)
+	entry.TargetUser = img.UserID
+	entry.TargetImage = img.ID
+	entry.Record(img, nil)
 
 	return img, nil
 }
 
-var ModCache = cache.New(24*time.Hour, 1*time.Hour)
+// uncaches a deleted image and takes its file down once the deletion is committed
+func dropImageFile(img *utils.Img, actorId uint64) error {
+	currentImages = deleteImage(img.ID)
 
-func GetModCached(modID string) (*utils.Mod, error) {
-	if modID == "" {
-		return nil, fmt.Errorf("no mod id provided")
+	// removals by anyone but the owner can be appealed, so the file is kept
+	if actorId != img.UserID {
+		return archiveImageFile(img)
 	}
 
-	if cached, found := ModCache.Get(modID); found {
-		mod := cached.(utils.Mod)
-		return &mod, nil
+	adDir := filepath.Join("..", "cdn", img.FileName())
+	err := os.Remove(adDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	apiURL := fmt.Sprintf("https://api.geode-sdk.org/v1/mods/%s", modID)
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch mod info: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("mod API returned status %d", resp.StatusCode)
-	}
-
-	var modReq utils.ModRequest
-	if err := json.NewDecoder(resp.Body).Decode(&modReq); err != nil {
-		return nil, fmt.Errorf("failed to decode mod API response: %w", err)
-	}
-
-	ModCache.Set(modID, modReq.Payload, cache.DefaultExpiration)
+	return nil
+}
 
-	return &modReq.Payload, nil
+// where a removed image's file is kept while it can be appealed
+func archivedFile(imgId uint64) string {
+	return filepath.Join(archiveDir, fmt.Sprintf("%d.webp", imgId))
 }
 
-func ResolveDevFromModID(modID string, dev string) (*utils.ModDeveloper, error) {
-	mod, err := GetModCached(modID)
-	if err != nil {
-		return nil, err
+// moves an image file out of the public CDN folder, tolerating it being gone already
+func archiveImageFile(img *utils.Img) error {
+	if err := os.MkdirAll(archiveDir, os.ModePerm); err != nil {
+		return err
 	}
 
-	for _, devInfo := range mod.Developers {
-		if devInfo.IsOwner {
-			return &devInfo, nil
-		}
+	err := os.Rename(filepath.Join("..", "cdn", img.FileName()), archivedFile(img.ID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
 	}
 
-	return nil, fmt.Errorf("developer %s not found in mod %s", dev, modID)
+	return nil
+}
+
+// moves an archived image file back into the public CDN folder
+func restoreImageFile(img *utils.Img) error {
+	return os.Rename(archivedFile(img.ID), filepath.Join("..", "cdn", img.FileName()))
 }
 
 func init() {
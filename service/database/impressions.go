@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"service/utils"
+)
+
+// adds a batch of counters in one transaction
+func AddImpressions(counts []*utils.ImpressionCount) error {
+	if dat == nil {
+		return fmt.Errorf("database connection non-existent")
+	}
+
+	tx, err := dat.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// selected from users so counters of users deleted since they were counted insert nothing instead of failing the batch
+	stmt, err := tx.Prepare("INSERT INTO impressions (user_id, mod_id, day, count) SELECT id, ?, ?, ? FROM users WHERE id = ? ON DUPLICATE KEY UPDATE impressions.count = impressions.count + VALUES(count)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range counts {
+		if _, err := stmt.Exec(c.ModID, c.Day, c.Count, c.UserID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sums impressions grouped by a query, scanning the group into each count
+func sumImpressions(stmtSql string, scan func(rows *sql.Rows, c *utils.ImpressionCount) error, args ...any) ([]*utils.ImpressionCount, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.ImpressionCount, 0)
+	for rows.Next() {
+		c := new(utils.ImpressionCount)
+		if err := scan(rows, c); err != nil {
+			return nil, err
+		}
+
+		out = append(out, c)
+	}
+
+	return out, rows.Err()
+}
+
+// a developer's impressions per day and per mod since a day
+func GetImpressionStats(userId uint64, since time.Time) (*utils.ImpressionStats, error) {
+	day := since.UTC().Format(time.DateOnly)
+
+	days, err := sumImpressions(
+		"SELECT DATE_FORMAT(day, '%Y-%m-%d'), SUM(count) FROM impressions WHERE user_id = ? AND day >= ? GROUP BY day ORDER BY day",
+		func(rows *sql.Rows, c *utils.ImpressionCount) error {
+			return rows.Scan(&c.Day, &c.Count)
+		},
+		userId, day,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	mods, err := sumImpressions(
+		"SELECT mod_id, SUM(count) AS total FROM impressions WHERE user_id = ? AND day >= ? GROUP BY mod_id ORDER BY total DESC",
+		func(rows *sql.Rows, c *utils.ImpressionCount) error {
+			return rows.Scan(&c.ModID, &c.Count)
+		},
+		userId, day,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &utils.ImpressionStats{Since: since, Days: days, Mods: mods}
+	for _, c := range days {
+		out.Total += c.Count
+	}
+
+	return out, nil
+}
+
+// the most seen developers and mods since a day
+func GetImpressionLeaders(since time.Time, limit int) (*utils.ImpressionLeaders, error) {
+	day := since.UTC().Format(time.DateOnly)
+
+	devs, err := sumImpressions(
+		"SELECT user_id, SUM(count) AS total FROM impressions WHERE day >= ? GROUP BY user_id ORDER BY total DESC LIMIT ?",
+		func(rows *sql.Rows, c *utils.ImpressionCount) error {
+			return rows.Scan(&c.UserID, &c.Count)
+		},
+		day, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range devs {
+		if u, err := GetUser(c.UserID); err == nil {
+			c.Login = u.Login
+		}
+	}
+
+	// impressions without a mod would otherwise top the list
+	mods, err := sumImpressions(
+		"SELECT mod_id, SUM(count) AS total FROM impressions WHERE day >= ? AND mod_id <> '' GROUP BY mod_id ORDER BY total DESC LIMIT ?",
+		func(rows *sql.Rows, c *utils.ImpressionCount) error {
+			return rows.Scan(&c.ModID, &c.Count)
+		},
+		day, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &utils.ImpressionLeaders{Since: since, Developers: devs, Mods: mods}
+
+	stmt, err := utils.PrepareStmt(dat, "SELECT COALESCE(SUM(count), 0) FROM impressions WHERE day >= ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRow(day).Scan(&out.Total); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
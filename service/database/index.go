@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 
 	"service/utils"
 )
@@ -11,3 +12,7 @@ var dat *sql.DB
 func init() {
 	dat = utils.Db()
 }
+
+func errNotFound(kind string, id any) error {
+	return fmt.Errorf("%s %v not found", kind, id)
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"service/geode"
+	"service/utils"
+)
+
+// looks up the GitHub login owning a Geode developer name
+func aliasOwner(alias string) (string, bool) {
+	user, err := GetAliasUser(alias)
+	if err != nil {
+		return "", false
+	}
+
+	return user.Login, true
+}
+
+// names a developer goes by, their own and the login behind it if it is an alias
+func devIdentities(name string, owner func(string) (string, bool)) []string {
+	out := []string{name}
+	if login, found := owner(name); found {
+		out = append(out, login)
+	}
+
+	return out
+}
+
+func sameDeveloper(a []string, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x != "" && strings.EqualFold(x, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matches a requested developer against a mod's developers, in order:
+//   - no developer requested: the mod owner
+//   - a developer listed under the same name, ignoring case
+//   - a developer sharing a login with the requested name through aliases
+//   - the requested name owns the mod's source repository: the mod owner
+//
+// anything else is not found rather than falling back to the owner, so co-developers never get the owner's branding
+func matchModDeveloper(mod *geode.Mod, dev string, owner func(string) (string, bool)) (*geode.ModDeveloper, string, error) {
+	var modOwner *geode.ModDeveloper
+	for i := range mod.Developers {
+		if mod.Developers[i].IsOwner {
+			modOwner = &mod.Developers[i]
+			break
+		}
+	}
+
+	if dev == "" {
+		if modOwner != nil {
+			return modOwner, utils.ResolveModOwner, nil
+		}
+
+		return nil, "", fmt.Errorf("mod %s has no owner", mod.ID)
+	}
+
+	for i := range mod.Developers {
+		if strings.EqualFold(mod.Developers[i].Username, dev) {
+			return &mod.Developers[i], utils.ResolveModDeveloper, nil
+		}
+	}
+
+	requested := devIdentities(dev, owner)
+	for i := range mod.Developers {
+		if sameDeveloper(requested, devIdentities(mod.Developers[i].Username, owner)) {
+			return &mod.Developers[i], utils.ResolveModAlias, nil
+		}
+	}
+
+	if modOwner != nil {
+		if source, err := mod.Links.SourceOwner(); err == nil && sameDeveloper(requested, []string{source}) {
+			return modOwner, utils.ResolveModSource, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("developer %s not found in mod %s", dev, mod.ID)
+}
+
+// resolves which of a mod's developers was requested, and by which rule
+func ResolveDevFromModID(client *geode.Client, modID string, dev string) (*geode.ModDeveloper, string, error) {
+	return resolveModDeveloper(client, modID, dev, aliasOwner)
+}
+
+func resolveModDeveloper(client *geode.Client, modID string, dev string, owner func(string) (string, bool)) (*geode.ModDeveloper, string, error) {
+	mod, err := client.GetMod(modID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return matchModDeveloper(mod, dev, owner)
+}
+
+// whether a mod lists the user among its developers, under their login or a claimed alias
+func ModListsUser(mod *geode.Mod, user *utils.User) bool {
+	for _, dev := range mod.Developers {
+		if sameDeveloper(devIdentities(dev.Username, aliasOwner), []string{user.Login}) {
+			return true
+		}
+	}
+
+	return false
+}
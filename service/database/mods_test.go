@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"service/geode"
+	"service/geode/geodetest"
+	"service/utils"
+)
+
+// claimed aliases standing in for the developer_aliases table
+func stubAliases(owners map[string]string) func(string) (string, bool) {
+	return func(alias string) (string, bool) {
+		login, found := owners[strings.ToLower(alias)]
+		return login, found
+	}
+}
+
+func TestResolveModDeveloper(t *testing.T) {
+	srv := geodetest.NewServer(
+		geode.Mod{
+			ID: "team.mod",
+			Developers: []geode.ModDeveloper{
+				{Username: "TeamLead", IsOwner: true},
+				{Username: "helper"},
+				{Username: "artist-geode"},
+			},
+			Links: geode.ModLinks{Source: "https://github.com/lead-gh/team-mod"},
+		},
+		geode.Mod{
+			ID:         "orphan.mod",
+			Developers: []geode.ModDeveloper{{Username: "someone"}},
+		},
+	)
+	defer srv.Close()
+
+	client := srv.Client()
+	owners := stubAliases(map[string]string{
+		"artist-geode": "artist-gh",
+		"teamlead":     "lead-gh",
+	})
+
+	tests := []struct {
+		name    string
+		mod     string
+		dev     string
+		want    string
+		path    string
+		wantErr error
+	}{
+		{name: "owner when no developer", mod: "team.mod", dev: "", want: "TeamLead", path: utils.ResolveModOwner},
+		{name: "same name ignoring case", mod: "team.mod", dev: "HELPER", want: "helper", path: utils.ResolveModDeveloper},
+		{name: "login behind listed alias", mod: "team.mod", dev: "artist-gh", want: "artist-geode", path: utils.ResolveModAlias},
+		{name: "login behind owner alias", mod: "team.mod", dev: "LEAD-GH", want: "TeamLead", path: utils.ResolveModAlias},
+		{name: "unlisted developer", mod: "team.mod", dev: "stranger"},
+		{name: "no owner to fall back to", mod: "orphan.mod", dev: ""},
+		{name: "mod not in index", mod: "missing.mod", dev: "helper", wantErr: geode.ErrModNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, path, err := resolveModDeveloper(client, tt.mod, tt.dev, owners)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("resolved %q by %s, want an error", dev.Username, path)
+				}
+
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resolveModDeveloper: %v", err)
+			}
+
+			if dev.Username != tt.want || path != tt.path {
+				t.Errorf("resolved %q by %s, want %q by %s", dev.Username, path, tt.want, tt.path)
+			}
+		})
+	}
+}
+
+// the source repository rule only applies when no alias links the name to a listed developer
+func TestResolveModDeveloperSourceOwner(t *testing.T) {
+	srv := geodetest.NewServer(geode.Mod{
+		ID: "solo.mod",
+		Developers: []geode.ModDeveloper{
+			{Username: "geode-name", IsOwner: true},
+			{Username: "helper"},
+		},
+		Links: geode.ModLinks{Source: "https://www.github.com/Repo-Owner/solo"},
+	})
+	defer srv.Close()
+
+	dev, path, err := resolveModDeveloper(srv.Client(), "solo.mod", "repo-owner", stubAliases(nil))
+	if err != nil {
+		t.Fatalf("resolveModDeveloper: %v", err)
+	}
+
+	if dev.Username != "geode-name" || path != utils.ResolveModSource {
+		t.Errorf("resolved %q by %s, want geode-name by %s", dev.Username, path, utils.ResolveModSource)
+	}
+
+	// a co-developer's repository never hands out the owner's branding
+	srv.SetMod(geode.Mod{
+		ID:         "shared.mod",
+		Developers: []geode.ModDeveloper{{Username: "geode-name", IsOwner: true}},
+		Links:      geode.ModLinks{Source: "https://gitlab.com/repo-owner/shared"},
+	})
+
+	if dev, path, err := resolveModDeveloper(srv.Client(), "shared.mod", "repo-owner", stubAliases(nil)); err == nil {
+		t.Errorf("resolved %q by %s through a non-GitHub source, want an error", dev.Username, path)
+	}
+}
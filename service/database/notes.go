@@ -0,0 +1,105 @@
+package database
+
+import (
+	"database/sql"
+
+	"service/utils"
+)
+
+func ListNotesForUser(userId uint64) ([]*utils.StaffNote, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM staff_notes WHERE user_id = ? ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.StaffNote, 0)
+	for rows.Next() {
+		n := new(utils.StaffNote)
+		if err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.AuthorID,
+			&n.Note,
+			&n.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, n := range out {
+		if author, err := GetUser(n.AuthorID); err == nil {
+			n.AuthorLogin = author.Login
+		}
+	}
+
+	return out, nil
+}
+
+func CreateNote(userId uint64, note string, entry *utils.AuditEntry) (uint64, error) {
+	var id uint64
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT INTO staff_notes (user_id, author_id, note) VALUES (?, ?, LEFT(?, 2000))", userId, entry.ActorID, note)
+		if err != nil {
+			return err
+		}
+
+		last, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = uint64(last)
+
+		entry.TargetUser = userId
+		entry.Record(nil, &utils.StaffNote{ID: id, UserID: userId, AuthorID: entry.ActorID, Note: note})
+
+		return nil
+	})
+
+	return id, err
+}
+
+// removes a note, only its author can unless force is set
+func DeleteNote(id uint64, force bool, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		n := new(utils.StaffNote)
+		err := tx.QueryRow("SELECT * FROM staff_notes WHERE id = ? FOR UPDATE", id).Scan(
+			&n.ID,
+			&n.UserID,
+			&n.AuthorID,
+			&n.Note,
+			&n.Created,
+		)
+		if err == sql.ErrNoRows {
+			return errNotFound("staff note", id)
+		} else if err != nil {
+			return err
+		}
+
+		if !force && n.AuthorID != entry.ActorID {
+			return ErrConflict
+		}
+
+		if _, err := tx.Exec("DELETE FROM staff_notes WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		entry.TargetUser = n.UserID
+		entry.Record(n, nil)
+
+		return nil
+	})
+}
@@ -0,0 +1,561 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"service/utils"
+
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	ErrLastOwner = errors.New("organization needs at least one owner")
+	ErrLastMod   = errors.New("organization needs at least one mod")
+)
+
+// user ID to the organization whose branding they show, 0 for none
+var optInCache = cache.New(1*time.Hour, 10*time.Minute)
+
+// scans a row of SELECT * FROM organizations
+func scanOrg(row rowScanner) (*utils.Org, error) {
+	o := new(utils.Org)
+	err := row.Scan(
+		&o.ID,
+		&o.Name,
+		&o.CreatedBy,
+		&o.Created,
+	)
+
+	return o, err
+}
+
+func scanOrgs(stmtSql string, args ...any) ([]*utils.Org, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Org, 0)
+	for rows.Next() {
+		o, err := scanOrg(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, o := range out {
+		if err := describeOrg(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// fills in the mods and members of an organization
+func describeOrg(o *utils.Org) error {
+	stmt, err := utils.PrepareStmt(dat, "SELECT mod_id FROM organization_mods WHERE org_id = ? ORDER BY mod_id")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(o.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	o.Mods = make([]string, 0)
+	for rows.Next() {
+		var modId string
+		if err := rows.Scan(&modId); err != nil {
+			return err
+		}
+
+		o.Mods = append(o.Mods, modId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	members, err := listOrgMembers(o.ID)
+	if err != nil {
+		return err
+	}
+
+	o.Members = members
+
+	return nil
+}
+
+func scanMembership(row rowScanner) (*utils.OrgMembership, error) {
+	m := new(utils.OrgMembership)
+	err := row.Scan(
+		&m.OrgID,
+		&m.UserID,
+		&m.Role,
+		&m.OptIn,
+		&m.Created,
+	)
+
+	return m, err
+}
+
+func listOrgMembers(orgId uint64) ([]*utils.OrgMembership, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM organization_members WHERE org_id = ? ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(orgId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.OrgMembership, 0)
+	for rows.Next() {
+		m, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range out {
+		if u, err := GetUser(m.UserID); err == nil {
+			m.Login = u.Login
+		}
+	}
+
+	return out, nil
+}
+
+func GetOrg(id uint64) (*utils.Org, error) {
+	orgs, err := scanOrgs("SELECT * FROM organizations WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orgs) <= 0 {
+		return nil, errNotFound("organization", id)
+	}
+
+	return orgs[0], nil
+}
+
+func ListOrgs() ([]*utils.Org, error) {
+	return scanOrgs("SELECT * FROM organizations ORDER BY name")
+}
+
+func ListOrgsForUser(userId uint64) ([]*utils.Org, error) {
+	return scanOrgs("SELECT o.* FROM organizations o JOIN organization_members m ON m.org_id = o.id WHERE m.user_id = ? ORDER BY o.name", userId)
+}
+
+func GetOrgMembership(orgId uint64, userId uint64) (*utils.OrgMembership, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT * FROM organization_members WHERE org_id = ? AND user_id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	m, err := scanMembership(stmt.QueryRow(orgId, userId))
+	if err == sql.ErrNoRows {
+		return nil, errNotFound("organization member", userId)
+	}
+
+	return m, err
+}
+
+// organization whose branding a user opted in to showing, 0 if they show their own
+func GetOptInOrg(userId uint64) (uint64, error) {
+	key := strconv.FormatUint(userId, 10)
+	if val, found := optInCache.Get(key); found {
+		return val.(uint64), nil
+	}
+
+	stmt, err := utils.PrepareStmt(dat, "SELECT org_id FROM organization_members WHERE user_id = ? AND opt_in = TRUE")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var orgId uint64
+	if err := stmt.QueryRow(userId).Scan(&orgId); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	optInCache.Set(key, orgId, cache.DefaultExpiration)
+
+	return orgId, nil
+}
+
+// members showing their organization's branding instead of their own, mapped to that organization, leaving out banned members
+func ListOptInMembers() (map[uint64]uint64, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT m.user_id, m.org_id FROM organization_members m JOIN users u ON u.id = m.user_id WHERE m.opt_in = TRUE AND u.banned = FALSE")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uint64]uint64)
+	for rows.Next() {
+		var userId, orgId uint64
+		if err := rows.Scan(&userId, &orgId); err != nil {
+			return nil, err
+		}
+
+		out[userId] = orgId
+	}
+
+	return out, rows.Err()
+}
+
+// founds an organization with its first owner and the mod proving the team
+func CreateOrg(name string, ownerId uint64, modId string, entry *utils.AuditEntry) (*utils.Org, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	var id uint64
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		var existing int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM organizations WHERE name = ?", name).Scan(&existing); err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return ErrConflict
+		}
+
+		res, err := tx.Exec("INSERT INTO organizations (name, created_by) VALUES (?, ?)", name, ownerId)
+		if err != nil {
+			return err
+		}
+
+		last, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		id = uint64(last)
+
+		if _, err := tx.Exec("INSERT INTO organization_members (org_id, user_id, role) VALUES (?, ?, ?)", id, ownerId, utils.OrgRoleOwner); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("INSERT INTO organization_mods (org_id, mod_id, added_by) VALUES (?, ?, ?)", id, modId, ownerId); err != nil {
+			return err
+		}
+
+		entry.TargetUser = ownerId
+		entry.Record(nil, map[string]any{"org_id": id, "name": name, "mod_id": modId})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetOrg(id)
+}
+
+// removes an organization along with its branding
+func DeleteOrg(id uint64, entry *utils.AuditEntry) error {
+	var imgs []*utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		org, err := scanOrg(tx.QueryRow("SELECT * FROM organizations WHERE id = ? FOR UPDATE", id))
+		if err == sql.ErrNoRows {
+			return errNotFound("organization", id)
+		} else if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query("SELECT * FROM images WHERE org_id = ?", id)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			img, err := scanImage(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			imgs = append(imgs, img)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM images WHERE org_id = ?", id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM organizations WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		entry.Record(org, nil)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// every member showing it is back to their own branding
+	optInCache.Flush()
+
+	for _, img := range imgs {
+		currentImages = deleteImage(img.ID)
+
+		if err := dropImageFile(img, entry.ActorID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// counts owners of an organization, locking its member rows
+func countOwners(tx *sql.Tx, orgId uint64) (int, error) {
+	var owners int
+	err := tx.QueryRow("SELECT COUNT(*) FROM organization_members WHERE org_id = ? AND role = ? FOR UPDATE", orgId, utils.OrgRoleOwner).Scan(&owners)
+
+	return owners, err
+}
+
+func AddOrgMember(orgId uint64, userId uint64, role string, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		var existing int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM organization_members WHERE org_id = ? AND user_id = ?", orgId, userId).Scan(&existing); err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return ErrConflict
+		}
+
+		if _, err := tx.Exec("INSERT INTO organization_members (org_id, user_id, role) VALUES (?, ?, ?)", orgId, userId, role); err != nil {
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.Record(nil, &utils.OrgMembership{OrgID: orgId, UserID: userId, Role: role})
+
+		return nil
+	})
+}
+
+// takes a user out of an organization, the last owner can't leave
+func RemoveOrgMember(orgId uint64, userId uint64, entry *utils.AuditEntry) error {
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanMembership(tx.QueryRow("SELECT * FROM organization_members WHERE org_id = ? AND user_id = ? FOR UPDATE", orgId, userId))
+		if err == sql.ErrNoRows {
+			return errNotFound("organization member", userId)
+		} else if err != nil {
+			return err
+		}
+
+		if before.Role == utils.OrgRoleOwner {
+			owners, err := countOwners(tx, orgId)
+			if err != nil {
+				return err
+			}
+
+			if owners <= 1 {
+				return ErrLastOwner
+			}
+		}
+
+		if _, err := tx.Exec("DELETE FROM organization_members WHERE org_id = ? AND user_id = ?", orgId, userId); err != nil {
+			return err
+		}
+
+		entry.TargetUser = userId
+		entry.Record(before, nil)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	optInCache.Delete(strconv.FormatUint(userId, 10))
+
+	return nil
+}
+
+// promotes or demotes a member, the last owner can't step down
+func SetOrgRole(orgId uint64, userId uint64, role string, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanMembership(tx.QueryRow("SELECT * FROM organization_members WHERE org_id = ? AND user_id = ? FOR UPDATE", orgId, userId))
+		if err == sql.ErrNoRows {
+			return errNotFound("organization member", userId)
+		} else if err != nil {
+			return err
+		}
+
+		if before.Role == role {
+			return nil
+		}
+
+		if before.Role == utils.OrgRoleOwner {
+			owners, err := countOwners(tx, orgId)
+			if err != nil {
+				return err
+			}
+
+			if owners <= 1 {
+				return ErrLastOwner
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE organization_members SET role = ? WHERE org_id = ? AND user_id = ?", role, orgId, userId); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Role = role
+
+		entry.TargetUser = userId
+		entry.Record(before, &after)
+
+		return nil
+	})
+}
+
+// turns showing the team branding on or off for a member, opting in to one organization opts out of the others
+func SetOrgOptIn(orgId uint64, userId uint64, optIn bool, entry *utils.AuditEntry) error {
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanMembership(tx.QueryRow("SELECT * FROM organization_members WHERE org_id = ? AND user_id = ? FOR UPDATE", orgId, userId))
+		if err == sql.ErrNoRows {
+			return errNotFound("organization member", userId)
+		} else if err != nil {
+			return err
+		}
+
+		if optIn {
+			if _, err := tx.Exec("UPDATE organization_members SET opt_in = FALSE WHERE user_id = ? AND org_id <> ?", userId, orgId); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec("UPDATE organization_members SET opt_in = ? WHERE org_id = ? AND user_id = ?", optIn, orgId, userId); err != nil {
+			return err
+		}
+
+		after := *before
+		after.OptIn = optIn
+
+		entry.TargetUser = userId
+		entry.Record(before, &after)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	optInCache.Delete(strconv.FormatUint(userId, 10))
+
+	return nil
+}
+
+func AddOrgMod(orgId uint64, modId string, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT IGNORE INTO organization_mods (org_id, mod_id, added_by) VALUES (?, ?, ?)", orgId, modId, entry.ActorID)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrConflict
+		}
+
+		entry.Record(nil, map[string]any{"org_id": orgId, "mod_id": modId})
+
+		return nil
+	})
+}
+
+// stops listing a mod as proof of the team, the last mod can't go
+func RemoveOrgMod(orgId uint64, modId string, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		var mods int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM organization_mods WHERE org_id = ? FOR UPDATE", orgId).Scan(&mods); err != nil {
+			return err
+		}
+
+		if mods <= 1 {
+			return ErrLastMod
+		}
+
+		res, err := tx.Exec("DELETE FROM organization_mods WHERE org_id = ? AND mod_id = ?", orgId, modId)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errNotFound("organization mod", modId)
+		}
+
+		entry.Record(map[string]any{"org_id": orgId, "mod_id": modId}, nil)
+
+		return nil
+	})
+}
+
+// the branding of an organization
+func GetOrgImage(orgId uint64) (*utils.Img, error) {
+	imgs, err := ListAllImages()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, img := range imgs {
+		if img.OrgID() == orgId {
+			return img, nil
+		}
+	}
+
+	return nil, errNotFound("organization branding", orgId)
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"service/utils"
+)
+
+func scanOutbox(stmtSql string, args ...any) ([]*utils.OutboxMessage, error) {
+	stmt, err := utils.PrepareStmt(dat, stmtSql)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.OutboxMessage, 0)
+	for rows.Next() {
+		m := new(utils.OutboxMessage)
+		if err := rows.Scan(
+			&m.ID,
+			&m.Channel,
+			&m.Payload,
+			&m.Status,
+			&m.Attempts,
+			&m.LastError,
+			&m.NextAttempt,
+			&m.Created,
+			&m.Sent,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, m)
+	}
+
+	return out, rows.Err()
+}
+
+// queues a Discord notification for delivery
+func EnqueueOutbox(channel string, payload []byte) (uint64, error) {
+	stmt, err := utils.PrepareStmt(dat, "INSERT INTO discord_outbox (channel, payload) VALUES (?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(channel, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := res.LastInsertId()
+	return uint64(last), err
+}
+
+// fetches pending notifications whose next attempt is due, oldest first
+func ListDueOutbox(limit int) ([]*utils.OutboxMessage, error) {
+	return scanOutbox("SELECT * FROM discord_outbox WHERE status = ? AND next_attempt_at <= NOW() ORDER BY id LIMIT ?", utils.OutboxPending, limit)
+}
+
+func ListOutbox(status string) ([]*utils.OutboxMessage, error) {
+	return scanOutbox("SELECT * FROM discord_outbox WHERE status = ? ORDER BY id DESC LIMIT 500", status)
+}
+
+func MarkOutboxSent(id uint64) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE discord_outbox SET status = ?, sent_at = NOW() WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(utils.OutboxSent, id)
+	return err
+}
+
+// records a failed delivery and schedules the next attempt, counting it towards the retry limit if asked to
+func MarkOutboxRetry(id uint64, next time.Time, countAttempt bool, reason string) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE discord_outbox SET attempts = attempts + ?, next_attempt_at = ?, last_error = LEFT(?, 1024) WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	inc := 0
+	if countAttempt {
+		inc = 1
+	}
+
+	_, err = stmt.Exec(inc, next.UTC(), reason, id)
+	return err
+}
+
+func MarkOutboxDead(id uint64, reason string) error {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE discord_outbox SET status = ?, attempts = attempts + 1, last_error = LEFT(?, 1024) WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(utils.OutboxDead, reason, id)
+	return err
+}
+
+// puts a dead-lettered notification back in the queue
+func RequeueOutbox(id uint64, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		res, err := tx.Exec("UPDATE discord_outbox SET status = ?, attempts = 0, next_attempt_at = NOW() WHERE id = ? AND status = ?", utils.OutboxPending, id, utils.OutboxDead)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n <= 0 {
+			return fmt.Errorf("no dead-lettered message of ID %d", id)
+		}
+
+		entry.Record(map[string]any{"id": id, "status": utils.OutboxDead}, map[string]any{"id": id, "status": utils.OutboxPending})
+
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+
+	"service/utils"
+)
+
+// files a report against an image, returning the open report count and false if the reporter already reported it
+func CreateReport(imageId uint64, category string, note string, reporter string) (int, bool, error) {
+	stmt, err := utils.PrepareStmt(dat, "INSERT IGNORE INTO reports (image_id, category, note, reporter) VALUES (?, ?, LEFT(?, 500), ?)")
+	if err != nil {
+		return 0, false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(imageId, category, note, reporter)
+	if err != nil {
+		return 0, false, err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+
+	countStmt, err := utils.PrepareStmt(dat, "SELECT COUNT(*) FROM reports WHERE image_id = ? AND status = ?")
+	if err != nil {
+		return 0, false, err
+	}
+	defer countStmt.Close()
+
+	var count int
+	if err := countStmt.QueryRow(imageId, utils.ReportOpen).Scan(&count); err != nil {
+		return 0, false, err
+	}
+
+	return count, inserted > 0, nil
+}
+
+func ListOpenReports() ([]*utils.Report, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT id, image_id, category, note, status, created_at FROM reports WHERE status = ? ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(utils.ReportOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]*utils.Report, 0)
+	for rows.Next() {
+		r := new(utils.Report)
+		if err := rows.Scan(
+			&r.ID,
+			&r.ImageID,
+			&r.Category,
+			&r.Note,
+			&r.Status,
+			&r.Created,
+		); err != nil {
+			return nil, err
+		}
+
+		out = append(out, r)
+	}
+
+	return out, rows.Err()
+}
+
+// closes the open reports against an image, returning how many there were
+func resolveReports(tx *sql.Tx, imageId uint64) (int64, error) {
+	res, err := tx.Exec("UPDATE reports SET status = ? WHERE image_id = ? AND status = ?", utils.ReportResolved, imageId, utils.ReportOpen)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// closes the open reports against an image without acting on it
+func DismissReports(imageId uint64, entry *utils.AuditEntry) error {
+	return withAudit(entry, func(tx *sql.Tx) error {
+		n, err := resolveReports(tx, imageId)
+		if err != nil {
+			return err
+		}
+
+		if n <= 0 {
+			return errNotFound("open reports on img", imageId)
+		}
+
+		entry.TargetImage = imageId
+		entry.Record(map[string]any{"open_reports": n}, map[string]any{"open_reports": 0})
+
+		return nil
+	})
+}
+
+// sends a live image back to the review queue and closes its reports, keeping the file
+func UnpublishImage(imgId uint64, entry *utils.AuditEntry) (*utils.Img, error) {
+	var img *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ? FOR UPDATE", imgId))
+		if err != nil {
+			return err
+		}
+
+		if before.Pending {
+			return ErrConflict
+		}
+
+		if _, err := tx.Exec("UPDATE images SET pending = TRUE, version = version + 1 WHERE id = ?", imgId); err != nil {
+			return err
+		}
+
+		if _, err := resolveReports(tx, imgId); err != nil {
+			return err
+		}
+
+		img, err = scanImage(tx.QueryRow("SELECT * FROM images WHERE id = ?", imgId))
+		if err != nil {
+			return err
+		}
+
+		entry.TargetUser = img.UserID
+		entry.TargetImage = img.ID
+		entry.Record(before, img)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, found := findImage(imgId); found {
+		cached.Pending = true
+		cached.Version = img.Version
+		currentImages = setImage(cached)
+	}
+
+	return img, nil
+}
+
+// groups open reports per image for the staff queue, most reported first
+func ListReportGroups() ([]*utils.ReportGroup, error) {
+	reports, err := ListOpenReports()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[uint64]*utils.ReportGroup)
+	out := make([]*utils.ReportGroup, 0)
+	for _, r := range reports {
+		g, found := groups[r.ImageID]
+		if !found {
+			img, err := GetImage(r.ImageID)
+			if err != nil {
+				return nil, err
+			}
+
+			g = &utils.ReportGroup{
+				Image:      img,
+				Categories: make(map[string]int),
+				Notes:      make([]string, 0),
+				First:      r.Created,
+			}
+
+			if owner, err := GetUser(img.UserID); err == nil {
+				g.Login = owner.Login
+			}
+
+			groups[r.ImageID] = g
+			out = append(out, g)
+		}
+
+		g.Count++
+		g.Categories[r.Category]++
+		g.Last = r.Created
+
+		if r.Note != "" {
+			g.Notes = append(g.Notes, r.Note)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"service/utils"
+)
+
+var ErrWindowOver = errors.New("scheduled window has already ended")
+
+// the image to serve for a user right now, in order:
+//   - their scheduled branding in its window
+//   - the branding of the organization they opted in to
+//   - their variant for the theme
+//   - their default branding
+//
+// the team branding comes before theme variants on purpose, opting in shows the team in every theme
+// rather than only where the member hasn't uploaded a variant. callers refuse banned users themselves,
+// as an organization's row survives its members' bans.
+func GetActiveImage(userId uint64, theme string, now time.Time) (*utils.Img, error) {
+	imgs, err := ListAllImages()
+	if err != nil {
+		return nil, err
+	}
+
+	var active *utils.Img
+	for _, img := range imgs {
+		if img.UserID != userId || !img.Scheduled() || !img.LiveAt(now) {
+			continue
+		}
+
+		// overlapping windows go to the one that started last
+		if active == nil || img.Starts.After(*active.Starts) {
+			active = img
+		}
+	}
+
+	if active != nil {
+		return active, nil
+	}
+
+	if orgId, err := GetOptInOrg(userId); err == nil && orgId != 0 {
+		if team, err := GetOrgImage(orgId); err == nil && team.LiveAt(now) {
+			return team, nil
+		}
+	}
+
+	if theme != "" {
+		if variant, err := GetImageForSlot(userId, utils.ThemeSlot(theme)); err == nil && variant.LiveAt(now) {
+			return variant, nil
+		}
+	}
+
+	return GetImageForUser(userId)
+}
+
+// the image to serve right now for every user with one, in the same order as GetActiveImage without a theme, built in one pass over imgs for listings covering everyone
+func ListActiveImages(imgs []*utils.Img, now time.Time) (map[uint64]*utils.Img, error) {
+	optIn, err := ListOptInMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make(map[uint64]*utils.Img)
+	defaults := make(map[uint64]*utils.Img)
+	teams := make(map[uint64]*utils.Img)
+	for _, img := range imgs {
+		switch {
+		case img.OrgID() != 0:
+			teams[img.OrgID()] = img
+		case img.Scheduled():
+			if current, found := scheduled[img.UserID]; img.LiveAt(now) && (!found || img.Starts.After(*current.Starts)) {
+				scheduled[img.UserID] = img
+			}
+		case img.Slot == utils.SlotDefault:
+			defaults[img.UserID] = img
+		}
+	}
+
+	out := make(map[uint64]*utils.Img, len(defaults)+len(optIn))
+	for userId, img := range defaults {
+		out[userId] = img
+	}
+
+	for userId, orgId := range optIn {
+		if team, found := teams[orgId]; found && team.LiveAt(now) {
+			out[userId] = team
+		}
+	}
+
+	for userId, img := range scheduled {
+		out[userId] = img
+	}
+
+	return out, nil
+}
+
+// scheduled brandings that haven't ended, soonest first, for one user or everyone when userId is 0
+func ListUpcomingImages(userId uint64, now time.Time) ([]*utils.Img, error) {
+	stmtSql := "SELECT * FROM images WHERE starts_at IS NOT NULL AND ends_at > ?"
+	args := []any{now}
+	if userId != 0 {
+		stmtSql += " AND user_id = ?"
+		args = append(args, userId)
+	}
+	stmtSql += " ORDER BY starts_at"
+
+	return scanImages(stmtSql, args...)
+}
+
+// approved scheduled brandings whose window started or ended without the scheduler catching up yet
+func ListDueSchedules(now time.Time) ([]*utils.Img, error) {
+	return scanImages(
+		"SELECT * FROM images WHERE pending = FALSE AND ((schedule_state = ? AND starts_at <= ?) OR (schedule_state IN (?, ?) AND ends_at <= ?)) ORDER BY starts_at",
+		utils.ScheduleWaiting, now, utils.ScheduleWaiting, utils.ScheduleLive, now,
+	)
+}
+
+// scheduled brandings whose window ended by a time that are done with, announced as expired or never approved in time
+func ListEndedSchedules(before time.Time) ([]*utils.Img, error) {
+	return scanImages(
+		"SELECT * FROM images WHERE starts_at IS NOT NULL AND ends_at <= ? AND (pending = TRUE OR schedule_state = ?)",
+		before, utils.ScheduleExpired,
+	)
+}
+
+// moves a scheduled branding along, reporting false if another instance already did
+func AdvanceSchedule(imgId uint64, from string, to string) (bool, error) {
+	stmt, err := utils.PrepareStmt(dat, "UPDATE images SET schedule_state = ? WHERE id = ? AND schedule_state = ?")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(to, imgId, from)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if n > 0 {
+		if cached, found := findImage(imgId); found {
+			cached.Schedule = to
+		}
+	}
+
+	return n > 0, nil
+}
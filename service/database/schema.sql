@@ -29,4 +29,235 @@ CREATE TABLE IF NOT EXISTS sessions (
     PRIMARY KEY (session_id),
     KEY idx_user_id (user_id),
     CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
-) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
\ No newline at end of file
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+CREATE TABLE IF NOT EXISTS developer_aliases (
+    alias VARCHAR(100) NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    mod_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_by BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (alias),
+    KEY idx_user_id (user_id),
+    CONSTRAINT fk_aliases_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS legacy BOOLEAN NOT NULL DEFAULT FALSE AFTER pending;
+
+CREATE TABLE IF NOT EXISTS discord_accounts (
+    discord_id VARCHAR(32) NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (discord_id),
+    UNIQUE KEY idx_user_id (user_id),
+    CONSTRAINT fk_discord_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS discord_outbox (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    channel VARCHAR(16) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    last_error VARCHAR(1024) NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (id),
+    KEY idx_status_next (status, next_attempt_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    url VARCHAR(512) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    events VARCHAR(512) NOT NULL DEFAULT '*',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    subscription_id BIGINT UNSIGNED NOT NULL,
+    event VARCHAR(64) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT UNSIGNED NOT NULL DEFAULT 0,
+    response_code INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(1024) NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (id),
+    KEY idx_status_next (status, next_attempt_at),
+    KEY idx_subscription (subscription_id),
+    CONSTRAINT fk_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    actor_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    action VARCHAR(32) NOT NULL,
+    target_user_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    target_image_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    state_before TEXT NULL DEFAULT NULL,
+    state_after TEXT NULL DEFAULT NULL,
+    reason VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_actor (actor_id, created_at),
+    KEY idx_target_user (target_user_id, created_at),
+    KEY idx_target_image (target_image_id),
+    KEY idx_action (action, created_at)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS version INT UNSIGNED NOT NULL DEFAULT 1 AFTER legacy;
+
+CREATE TABLE IF NOT EXISTS image_claims (
+    image_id BIGINT UNSIGNED NOT NULL,
+    staff_id BIGINT UNSIGNED NOT NULL,
+    claimed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (image_id),
+    KEY idx_staff (staff_id),
+    CONSTRAINT fk_claims_image FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_claims_staff FOREIGN KEY (staff_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS risk_score INT NOT NULL DEFAULT 0 AFTER version;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS flags TEXT NULL DEFAULT NULL AFTER risk_score;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS phash BIGINT UNSIGNED NULL DEFAULT NULL AFTER flags;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS dhash BIGINT UNSIGNED NULL DEFAULT NULL AFTER phash;
+
+CREATE TABLE IF NOT EXISTS hash_blocklist (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    phash BIGINT UNSIGNED NOT NULL,
+    dhash BIGINT UNSIGNED NOT NULL,
+    reason VARCHAR(1024) NOT NULL DEFAULT '',
+    source_image_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS reports (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    image_id BIGINT UNSIGNED NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    reporter CHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_image_reporter (image_id, reporter),
+    KEY idx_status (status, image_id),
+    CONSTRAINT fk_reports_image FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS appeals (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    audit_id BIGINT UNSIGNED NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    image_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    message VARCHAR(2000) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    reviewer_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_audit_id (audit_id),
+    KEY idx_user_id (user_id),
+    KEY idx_status (status, created_at),
+    CONSTRAINT fk_appeals_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS appeal_events (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    appeal_id BIGINT UNSIGNED NOT NULL,
+    actor_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    note VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_appeal_id (appeal_id),
+    CONSTRAINT fk_appeal_events_appeal FOREIGN KEY (appeal_id) REFERENCES appeals (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS staff_notes (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    author_id BIGINT UNSIGNED NOT NULL,
+    note VARCHAR(2000) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_user_id (user_id),
+    CONSTRAINT fk_staff_notes_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS image_votes (
+    image_id BIGINT UNSIGNED NOT NULL,
+    version INT UNSIGNED NOT NULL,
+    staff_id BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (image_id, version, staff_id),
+    CONSTRAINT fk_image_votes_image FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE images ADD COLUMN IF NOT EXISTS slot VARCHAR(64) NOT NULL DEFAULT '' AFTER dhash;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP NULL DEFAULT NULL AFTER slot;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP NULL DEFAULT NULL AFTER starts_at;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS schedule_state VARCHAR(16) NOT NULL DEFAULT '' AFTER ends_at;
+ALTER TABLE images ADD UNIQUE KEY IF NOT EXISTS idx_user_slot (user_id, slot);
+ALTER TABLE images DROP INDEX IF EXISTS idx_user_id;
+ALTER TABLE images ADD KEY IF NOT EXISTS idx_schedule (schedule_state, starts_at);
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    created_by BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_name (name)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    org_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member',
+    opt_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, user_id),
+    KEY idx_user_id (user_id),
+    CONSTRAINT fk_organization_members_org FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_organization_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS organization_mods (
+    org_id BIGINT UNSIGNED NOT NULL,
+    mod_id VARCHAR(255) NOT NULL,
+    added_by BIGINT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, mod_id),
+    CONSTRAINT fk_organization_mods_org FOREIGN KEY (org_id) REFERENCES organizations (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- organization brandings belong to the organization, so no member's ban or account removal takes them along
+ALTER TABLE images ADD COLUMN IF NOT EXISTS org_id BIGINT UNSIGNED NULL DEFAULT NULL AFTER schedule_state;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS submitted_by BIGINT UNSIGNED NULL DEFAULT NULL AFTER org_id;
+ALTER TABLE images MODIFY COLUMN user_id BIGINT UNSIGNED NULL;
+UPDATE images SET org_id = CAST(SUBSTRING(slot, 5) AS UNSIGNED), submitted_by = user_id, user_id = NULL WHERE slot LIKE 'org-%' AND org_id IS NULL;
+ALTER TABLE images ADD UNIQUE KEY IF NOT EXISTS idx_org_id (org_id);
+ALTER TABLE images ADD CONSTRAINT fk_images_org FOREIGN KEY IF NOT EXISTS (org_id) REFERENCES organizations (id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE TABLE IF NOT EXISTS impressions (
+    user_id BIGINT UNSIGNED NOT NULL,
+    mod_id VARCHAR(255) NOT NULL DEFAULT '',
+    day DATE NOT NULL,
+    count BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, mod_id, day),
+    KEY idx_day (day),
+    CONSTRAINT fk_impressions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
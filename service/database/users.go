@@ -1,15 +1,34 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"service/log"
 	"service/utils"
 )
 
+func scanUser(row rowScanner) (*utils.User, error) {
+	user := new(utils.User)
+	err := row.Scan(
+		&user.ID,
+		&user.Login,
+		&user.AvatarURL,
+		&user.IsAdmin,
+		&user.IsStaff,
+		&user.Verified,
+		&user.Banned,
+		&user.Created,
+		&user.Updated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func newUsers() *[]*utils.User {
 	return new([]*utils.User)
 }
@@ -209,14 +228,29 @@ func UpsertUser(id uint64, login string, avatarUrl string) error {
 	return err
 }
 
-func VerifyUser(id uint64) (*utils.User, error) {
-	stmt, err := utils.PrepareStmt(dat, "UPDATE users SET verified = TRUE WHERE id = ?")
-	if err != nil {
-		return nil, err
-	}
-	defer stmt.Close()
+func VerifyUser(id uint64, entry *utils.AuditEntry) (*utils.User, error) {
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanUser(tx.QueryRow("SELECT * FROM users WHERE id = ? FOR UPDATE", id))
+		if err != nil {
+			return err
+		}
 
-	_, err = stmt.Exec(id)
+		if _, err := tx.Exec("UPDATE users SET verified = TRUE WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE images SET pending = FALSE, version = version + 1 WHERE user_id = ? AND pending = TRUE", id); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Verified = true
+
+		entry.TargetUser = id
+		entry.Record(before, &after)
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -226,23 +260,15 @@ func VerifyUser(id uint64) (*utils.User, error) {
 		currentUsers = setUser(user)
 	}
 
-	approveStmt, err := utils.PrepareStmt(dat, "UPDATE images SET pending = FALSE WHERE user_id = ?")
-	if err != nil {
-		return nil, err
-	}
-	defer approveStmt.Close()
-
-	_, err = approveStmt.Exec(id)
-	if err != nil {
-		return nil, err
-	}
-
 	imgs, err := FilterImagesByUser(*getImages(), id)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, img := range imgs {
+		if img.Pending {
+			img.Version++
+		}
 		img.Pending = false
 		currentImages = setImage(img)
 	}
@@ -265,44 +291,56 @@ func StaffUser(id uint64) (*utils.User, error) {
 	return GetUser(id)
 }
 
-func BanUser(id uint64) (*utils.User, error) {
-	// delete all images associated with the user
-	deleteImgsStmt, err := utils.PrepareStmt(dat, "SELECT * FROM images WHERE user_id = ?")
-	if err != nil {
-		return nil, err
-	}
-	defer deleteImgsStmt.Close()
+func BanUser(id uint64, entry *utils.AuditEntry) (*utils.User, error) {
+	var user *utils.User
+	var img *utils.Img
+	err := withAudit(entry, func(tx *sql.Tx) error {
+		before, err := scanUser(tx.QueryRow("SELECT * FROM users WHERE id = ? FOR UPDATE", id))
+		if err != nil {
+			return err
+		}
 
-	img := new(utils.Img)
-	err = deleteImgsStmt.QueryRow(id).Scan(&img.ID, &img.UserID, &img.ImageURL, &img.Created, &img.Pending)
-	if err != nil {
-		return nil, err
-	}
+		img, err = scanImage(tx.QueryRow("SELECT * FROM images WHERE user_id = ? AND slot = ?", id, utils.SlotDefault))
+		if err == sql.ErrNoRows {
+			img = nil
+		} else if err != nil {
+			return err
+		}
 
-	imgDir := filepath.Join("..", "cdn", fmt.Sprintf("%d.webp", img.UserID))
-	err = os.Remove(imgDir)
-	if err != nil {
-		return nil, err
-	}
+		// ban the user
+		if _, err := tx.Exec("UPDATE users SET banned = TRUE WHERE id = ?", id); err != nil {
+			return err
+		}
 
-	user, err := GetUser(id)
-	if err != nil {
-		return nil, err
-	}
+		user = new(utils.User)
+		*user = *before
+		user.Banned = true
+
+		entry.TargetUser = id
+		if img != nil {
+			entry.TargetImage = img.ID
+		}
+		entry.Record(before, user)
 
-	// ban the user
-	stmt, err := utils.PrepareStmt(dat, "UPDATE users SET banned = TRUE WHERE id = ?")
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(id)
+	currentUsers = deleteUser(id)
+
+	// take the user's images down, keeping them in case the ban is appealed
+	imgs, err := scanImages("SELECT * FROM images WHERE user_id = ?", id)
 	if err != nil {
 		return nil, err
 	}
 
-	currentUsers = deleteUser(id)
+	for _, img := range imgs {
+		if err := archiveImageFile(img); err != nil {
+			return nil, err
+		}
+	}
 
 	return user, nil
 }
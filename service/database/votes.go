@@ -0,0 +1,105 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"service/log"
+	"service/utils"
+)
+
+var ErrAlreadyVoted = errors.New("staff member already approved this version")
+
+// staff approvals a new unverified submitter's image needs before it is published
+var approvalQuorum = 2
+
+// how long an account counts as new, 0 treats every unverified account as new
+var quorumAccountAge = 30 * 24 * time.Hour
+
+// approvals needed to publish an image of the owner
+func requiredApprovals(owner *utils.User) int {
+	if approvalQuorum <= 1 || owner.Verified || owner.IsStaff || owner.IsAdmin {
+		return 1
+	}
+
+	if quorumAccountAge > 0 && time.Since(owner.Created) > quorumAccountAge {
+		return 1
+	}
+
+	return approvalQuorum
+}
+
+// records a staff approval of the current version, returning how many it now has
+func castVote(tx *sql.Tx, img *utils.Img, staffId uint64) (int, error) {
+	res, err := tx.Exec("INSERT IGNORE INTO image_votes (image_id, version, staff_id) VALUES (?, ?, ?)", img.ID, img.Version, staffId)
+	if err != nil {
+		return 0, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n <= 0 {
+		return 0, ErrAlreadyVoted
+	}
+
+	var votes int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM image_votes WHERE image_id = ? AND version = ?", img.ID, img.Version).Scan(&votes); err != nil {
+		return 0, err
+	}
+
+	return votes, nil
+}
+
+// approvals each pending image has on its current version, with the quorum it needs
+func ListVotes() (map[uint64]*utils.ApprovalTally, error) {
+	stmt, err := utils.PrepareStmt(dat, "SELECT i.id, COALESCE(i.user_id, i.submitted_by), COUNT(v.staff_id) FROM images i LEFT JOIN image_votes v ON v.image_id = i.id AND v.version = i.version WHERE i.pending = TRUE GROUP BY i.id, i.user_id, i.submitted_by")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[uint64]*utils.ApprovalTally)
+	for rows.Next() {
+		var id, userId uint64
+		var votes int
+		if err := rows.Scan(&id, &userId, &votes); err != nil {
+			return nil, err
+		}
+
+		quorum := 1
+		if owner, err := GetUser(userId); err == nil {
+			quorum = requiredApprovals(owner)
+		}
+
+		out[id] = &utils.ApprovalTally{Votes: votes, Quorum: quorum}
+	}
+
+	return out, rows.Err()
+}
+
+func init() {
+	if val := os.Getenv("APPROVAL_QUORUM"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 1 {
+			approvalQuorum = n
+		} else {
+			log.Warn("Invalid APPROVAL_QUORUM %s, using %d", val, approvalQuorum)
+		}
+	}
+
+	if val := os.Getenv("APPROVAL_QUORUM_AGE"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			quorumAccountAge = d
+		} else {
+			log.Warn("Invalid APPROVAL_QUORUM_AGE %s, using %s", val, quorumAccountAge)
+		}
+	}
+}
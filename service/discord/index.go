@@ -3,6 +3,8 @@ package discord
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"service/database"
@@ -19,6 +21,12 @@ const (
 	WebAvatar = "https://github.com/BlueWitherer/ModDevBranding/blob/master/logo.png?raw=true"
 )
 
+// most embeds Discord accepts in one message
+const maxEmbeds = 10
+
+// longest value Discord accepts in an embed field
+const maxFieldLength = 1024
+
 const (
 	colorPrimary   = 11241556
 	colorSecondary = 11762602
@@ -58,19 +66,98 @@ func getSession(private bool) (*discordgo.Session, string, string, error) {
 	}
 }
 
-func getDevHyperlink(dev string) string {
-	return fmt.Sprintf("**[@%s](https://geode-sdk.org/mods?per_page=20&developer=%s&sort=recently_updated)**", dev, strings.ToLower(dev))
+// staff moderation buttons on submission messages
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+)
+
+func moderationCustomID(action string, imgId uint64, version uint64) string {
+	return fmt.Sprintf("branding:%s:%d:%d", action, imgId, version)
 }
 
-func WebhookAccept(img *utils.Img, staff *utils.User) error {
-	s, id, token, err := getSession(false)
+// splits a moderation button custom ID into its action, image ID and the image version it was posted for
+func ParseModerationCustomID(customId string) (string, uint64, uint64, bool) {
+	parts := strings.Split(customId, ":")
+	if (len(parts) != 3 && len(parts) != 4) || parts[0] != "branding" {
+		return "", 0, 0, false
+	}
+
+	if parts[1] != ActionApprove && parts[1] != ActionReject {
+		return "", 0, 0, false
+	}
+
+	imgId, err := strconv.ParseUint(parts[2], 10, 64)
 	if err != nil {
-		return err
+		return "", 0, 0, false
 	}
 
+	// buttons posted before versions were tracked carry none
+	var version uint64
+	if len(parts) == 4 {
+		version, err = strconv.ParseUint(parts[3], 10, 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+	}
+
+	return parts[1], imgId, version, true
+}
+
+func moderationComponents(imgId uint64, version uint64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Approve",
+					Style:    discordgo.SuccessButton,
+					CustomID: moderationCustomID(ActionApprove, imgId, version),
+				},
+				discordgo.Button{
+					Label:    "Reject",
+					Style:    discordgo.DangerButton,
+					CustomID: moderationCustomID(ActionReject, imgId, version),
+				},
+			},
+		},
+	}
+}
+
+// marks a staff submission message with the decision taken on it
+func ModerationOutcome(embeds []*discordgo.MessageEmbed, action string, staff *utils.User) []*discordgo.MessageEmbed {
+	outcome := fmt.Sprintf("❌ Rejected by [@%s](https://www.github.com/%s/)", staff.Login, staff.Login)
+	color := colorSecondary
+	if action == ActionApprove {
+		outcome = fmt.Sprintf("✅ Approved by [@%s](https://www.github.com/%s/)", staff.Login, staff.Login)
+		color = colorPrimary
+	}
+
+	out := make([]*discordgo.MessageEmbed, 0, len(embeds))
+	for i, embed := range embeds {
+		e := *embed
+		if i == 0 {
+			e.Color = color
+			e.Fields = append(e.Fields, &discordgo.MessageEmbedField{
+				Name:  "Outcome",
+				Value: outcome,
+			})
+		}
+
+		out = append(out, &e)
+	}
+
+	return out
+}
+
+func getDevHyperlink(dev string) string {
+	return fmt.Sprintf("**[@%s](https://geode-sdk.org/mods?per_page=20&developer=%s&sort=recently_updated)**", dev, strings.ToLower(dev))
+}
+
+// public announcement of a published image
+func acceptEmbed(img *utils.Img, staff *utils.User) (*discordgo.MessageEmbed, error) {
 	u, err := database.GetUser(img.UserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var mod string
@@ -80,44 +167,225 @@ func WebhookAccept(img *utils.Img, staff *utils.User) error {
 		mod = "<:ico:1325250328005967932> Developer is verified"
 	}
 
-	go func() {
-		_, err = s.WebhookExecute(id, token, false, &discordgo.WebhookParams{
+	return &discordgo.MessageEmbed{
+		Title: "✅ New Developer Branding",
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Developer",
+				Value:  getDevHyperlink(u.Login),
+				Inline: true,
+			},
+			{
+				Name:   "Moderator",
+				Value:  mod,
+				Inline: true,
+			},
+		},
+		Color: colorPrimary,
+		Image: &discordgo.MessageEmbedImage{
+			URL:      img.ImageURL,
+			ProxyURL: img.ImageURL,
+		},
+	}, nil
+}
+
+func WebhookAccept(img *utils.Img, staff *utils.User) error {
+	return WebhookAcceptBatch([]*utils.Img{img}, staff)
+}
+
+// announces several published images, packing as many embeds into each message as Discord allows
+func WebhookAcceptBatch(imgs []*utils.Img, staff *utils.User) error {
+	_, _, _, err := getSession(false)
+	if err != nil {
+		return err
+	}
+
+	embeds := make([]*discordgo.MessageEmbed, 0, len(imgs))
+	for _, img := range imgs {
+		embed, err := acceptEmbed(img, staff)
+		if err != nil {
+			return err
+		}
+
+		embeds = append(embeds, embed)
+	}
+
+	for chunk := range slices.Chunk(embeds, maxEmbeds) {
+		err := enqueue(channelPublic, &outboxPayload{
+			Params: &discordgo.WebhookParams{
+				Username:  WebName,
+				AvatarURL: WebAvatar,
+				Embeds:    chunk,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// posts one staff summary for a bulk moderation request
+func WebhookBulkSummary(action string, staff *utils.User, results []*utils.BulkResult) error {
+	_, _, _, err := getSession(true)
+	if err != nil {
+		return err
+	}
+
+	done := make([]string, 0, len(results))
+	failed := 0
+	for _, res := range results {
+		if !res.OK {
+			failed++
+			continue
+		}
+
+		login := strconv.FormatUint(res.Image.UserID, 10)
+		if u, err := database.GetUser(res.Image.UserID); err == nil {
+			login = u.Login
+		}
+
+		line := fmt.Sprintf("`%d` %s", res.ImageID, getDevHyperlink(login))
+		if res.Image.Approvals != nil {
+			line += fmt.Sprintf(" (%d/%d approvals)", res.Image.Approvals.Votes, res.Image.Approvals.Quorum)
+		}
+
+		done = append(done, line)
+	}
+
+	// trimmed at a line break, leaving room for the ellipsis
+	list := strings.Join(done, "\n")
+	if len(list) > maxFieldLength {
+		list = list[:strings.LastIndex(list[:maxFieldLength-4], "\n")+1] + "…"
+	}
+
+	if list == "" {
+		list = "None"
+	}
+
+	return enqueue(channelStaff, &outboxPayload{
+		Params: &discordgo.WebhookParams{
 			Username:  WebName,
 			AvatarURL: WebAvatar,
 			Embeds: []*discordgo.MessageEmbed{
 				{
-					Title: "✅ New Developer Branding",
+					Title: fmt.Sprintf("📦 Bulk %s", action),
 					Fields: []*discordgo.MessageEmbedField{
 						{
-							Name:   "Developer",
-							Value:  getDevHyperlink(u.Login),
+							Name:   "Moderator",
+							Value:  fmt.Sprintf("[@%s](https://www.github.com/%s/)", staff.Login, staff.Login),
 							Inline: true,
 						},
 						{
-							Name:   "Moderator",
-							Value:  mod,
+							Name:   "Applied",
+							Value:  strconv.Itoa(len(done)),
 							Inline: true,
 						},
+						{
+							Name:   "Failed",
+							Value:  strconv.Itoa(failed),
+							Inline: true,
+						},
+						{
+							Name:  "Images",
+							Value: list,
+						},
 					},
-					Color: colorPrimary,
+					Color: colorTertiary,
+				},
+			},
+		},
+	})
+}
+
+// posts a submission to staff, with approve and reject buttons when it waits for review
+func WebhookStaffSubmit(img *utils.Img, moderate bool) error {
+	_, _, _, err := getSession(true)
+	if err != nil {
+		return err
+	}
+
+	u, err := database.GetUser(img.UserID)
+	if err != nil {
+		return err
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Developer",
+			Value:  getDevHyperlink(u.Login),
+			Inline: true,
+		},
+	}
+
+	// staff should know where a team, variant or scheduled branding will show
+	if orgId := img.OrgID(); orgId != 0 {
+		name := fmt.Sprintf("#%d", orgId)
+		if org, err := database.GetOrg(orgId); err == nil {
+			name = org.Name
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Organization",
+			Value:  name,
+			Inline: true,
+		})
+	} else if img.Theme() != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Variant",
+			Value:  fmt.Sprintf("%s theme", img.Theme()),
+			Inline: true,
+		})
+	} else if img.Scheduled() {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Scheduled",
+			Value:  fmt.Sprintf("<t:%d:f> to <t:%d:f>", img.Starts.Unix(), img.Ends.Unix()),
+			Inline: true,
+		})
+	}
+
+	if len(img.Flags) > 0 {
+		lines := make([]string, 0, len(img.Flags))
+		for _, flag := range img.Flags {
+			lines = append(lines, fmt.Sprintf("⚠️ %s", flag.Message))
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("Screening (risk %d)", img.Risk),
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+
+	payload := &outboxPayload{
+		Params: &discordgo.WebhookParams{
+			Username:  WebName,
+			AvatarURL: WebAvatar,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:  "🕑 Branding Submission",
+					Fields: fields,
+					Color:  colorTertiary,
 					Image: &discordgo.MessageEmbedImage{
 						URL:      img.ImageURL,
 						ProxyURL: img.ImageURL,
 					},
 				},
 			},
-		})
+		},
+	}
 
-		if err != nil {
-			log.Error(err.Error())
-		}
-	}()
+	// buttons only render for webhooks owned by the application handling interactions
+	if moderate {
+		payload.ModerateImage = img.ID
+		payload.ModerateVersion = img.Version
+	}
 
-	return nil
+	return enqueue(channelStaff, payload)
 }
 
-func WebhookStaffSubmit(img *utils.Img) error {
-	s, id, token, err := getSession(true)
+func WebhookStaffReport(img *utils.Img, category string, count int) error {
+	_, _, _, err := getSession(true)
 	if err != nil {
 		return err
 	}
@@ -127,35 +395,44 @@ func WebhookStaffSubmit(img *utils.Img) error {
 		return err
 	}
 
-	go func() {
-		_, err = s.WebhookExecute(id, token, false, &discordgo.WebhookParams{
+	return enqueue(channelStaff, &outboxPayload{
+		Params: &discordgo.WebhookParams{
 			Username:  WebName,
 			AvatarURL: WebAvatar,
 			Embeds: []*discordgo.MessageEmbed{
 				{
-					Title: "🕑 Branding Submission",
+					Title: "🚩 Branding Reported",
 					Fields: []*discordgo.MessageEmbedField{
 						{
 							Name:   "Developer",
 							Value:  getDevHyperlink(u.Login),
 							Inline: true,
 						},
+						{
+							Name:   "Latest Reason",
+							Value:  category,
+							Inline: true,
+						},
+						{
+							Name:   "Open Reports",
+							Value:  strconv.Itoa(count),
+							Inline: true,
+						},
 					},
-					Color: colorTertiary,
+					Color: colorSecondary,
 					Image: &discordgo.MessageEmbedImage{
 						URL:      img.ImageURL,
 						ProxyURL: img.ImageURL,
 					},
 				},
 			},
-		})
-
-		if err != nil {
-			log.Error(err.Error())
-		}
-	}()
+		},
+	})
+}
 
-	return nil
+// starts delivering queued Discord messages, until DrainOutbox stops it
+func StartOutbox() {
+	go runOutbox()
 }
 
 func init() {
@@ -165,5 +442,8 @@ func init() {
 		return
 	}
 
+	// rate limits are rescheduled by the outbox instead of blocking it
+	s.ShouldRetryOnRateLimit = false
+
 	session = s
 }
@@ -0,0 +1,69 @@
+package interactions
+
+import (
+	"os"
+
+	"service/log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// gateway session while running in bot mode
+var bot *discordgo.Session
+
+// answers interactions received over the gateway, used when no interactions endpoint URL is configured
+func onInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if err := s.InteractionRespond(i.Interaction, handleInteraction(i.Interaction)); err != nil {
+		log.Error("Failed to respond to interaction: %s", err.Error())
+	}
+}
+
+// connects the bot and registers the /branding command, when DISCORD_BOT_TOKEN is set
+func startBot() {
+	token := os.Getenv("DISCORD_BOT_TOKEN")
+	if token == "" {
+		log.Debug("DISCORD_BOT_TOKEN is not set, Discord bot mode disabled")
+		return
+	}
+
+	s, err := discordgo.New("Bot " + token)
+	if err != nil {
+		log.Error("Failed to create Discord bot session: %s", err.Error())
+		return
+	}
+
+	s.Identify.Intents = discordgo.IntentsGuilds
+	s.AddHandler(onInteraction)
+
+	if err := s.Open(); err != nil {
+		log.Error("Failed to connect Discord bot: %s", err.Error())
+		return
+	}
+
+	guild := os.Getenv("DISCORD_GUILD_ID")
+	if _, err := s.ApplicationCommandCreate(s.State.User.ID, guild, brandingCommand); err != nil {
+		log.Error("Failed to register /%s command: %s", brandingCommand.Name, err.Error())
+	}
+
+	bot = s
+
+	log.Done("Discord bot connected as %s", s.State.User.Username)
+}
+
+// connects the optional bot in the background, until Close disconnects it
+func StartBot() {
+	go startBot()
+}
+
+// disconnects the bot, if it is running
+func Close() {
+	if bot == nil {
+		return
+	}
+
+	if err := bot.Close(); err != nil {
+		log.Error("Failed to close Discord bot: %s", err.Error())
+	}
+
+	bot = nil
+}
@@ -0,0 +1,321 @@
+package interactions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"service/database"
+	"service/log"
+	"service/moderation"
+	"service/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// most pending submissions listed in one reply
+const maxPendingListed = 10
+
+// staff moderation command, registered by the bot in DISCORD_GUILD_ID
+var brandingCommand = &discordgo.ApplicationCommand{
+	Name:        "branding",
+	Description: "Moderate developer branding",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "pending",
+			Description: "List submissions waiting for review",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "approve",
+			Description: "Publish a pending submission",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "Image ID",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "reject",
+			Description: "Delete a pending submission",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "Image ID",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "Reason recorded in the audit log",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "ban",
+			Description: "Ban a developer and remove their branding",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "login",
+					Description: "GitHub username",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "Reason recorded in the audit log",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "lookup",
+			Description: "Show a developer's account and branding",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "login",
+					Description: "GitHub username",
+					Required:    true,
+				},
+			},
+		},
+	},
+}
+
+// reply the whole channel sees
+func reply(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	}
+}
+
+func githubLink(login string) string {
+	return fmt.Sprintf("[@%s](https://www.github.com/%s/)", login, login)
+}
+
+func optionValue(opt *discordgo.ApplicationCommandInteractionDataOption, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, o := range opt.Options {
+		if o.Name == name {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func commandPending() *discordgo.InteractionResponse {
+	imgList, err := database.ListPendingImages()
+	if err != nil {
+		log.Error("Failed to list pending images: %s", err.Error())
+		return ephemeral("Failed to list pending submissions.")
+	}
+
+	if len(imgList) <= 0 {
+		return ephemeral("No submissions are waiting for review.")
+	}
+
+	claims, err := database.ListClaims()
+	if err != nil {
+		log.Warn("Failed to list review claims: %s", err.Error())
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%d pending submission(s)**\n", len(imgList))
+	for i, img := range imgList {
+		if i >= maxPendingListed {
+			fmt.Fprintf(&b, "…and %d more", len(imgList)-maxPendingListed)
+			break
+		}
+
+		login := "unknown"
+		if u, err := database.GetUser(img.UserID); err == nil {
+			login = u.Login
+		}
+
+		fmt.Fprintf(&b, "`%d` %s, submitted <t:%d:R> ([image](%s))", img.ID, githubLink(login), img.Created.Unix(), img.ImageURL)
+		if img.Risk > 0 {
+			fmt.Fprintf(&b, ", risk %d", img.Risk)
+		}
+		if claim, found := claims[img.ID]; found {
+			fmt.Fprintf(&b, ", reviewed by %s", githubLink(claim.StaffLogin))
+		}
+		b.WriteString("\n")
+	}
+
+	return ephemeral(b.String())
+}
+
+func commandModerate(action string, imgId int64, staff *utils.User, reason string) *discordgo.InteractionResponse {
+	if imgId <= 0 {
+		return ephemeral("Invalid image ID.")
+	}
+
+	var img *utils.Img
+	var err error
+	if action == "approve" {
+		img, err = moderation.Approve(uint64(imgId), 0, staff, reason)
+	} else {
+		img, err = moderation.Reject(uint64(imgId), 0, staff, reason)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return ephemeral(fmt.Sprintf("Image `%d` is being reviewed by someone else or is no longer pending.", imgId))
+	} else if errors.Is(err, database.ErrAlreadyVoted) {
+		return ephemeral(fmt.Sprintf("You already approved image `%d`, it needs another staff member.", imgId))
+	} else if errors.Is(err, database.ErrWindowOver) {
+		return ephemeral(fmt.Sprintf("Image `%d` is a scheduled branding whose window has already ended.", imgId))
+	} else if err != nil {
+		log.Error("Failed to %s img %d from Discord: %s", action, imgId, err.Error())
+		return ephemeral(fmt.Sprintf("Failed to %s image `%d`, it may not exist or already have been handled.", action, imgId))
+	}
+
+	if action == "approve" && img.Pending {
+		return reply(fmt.Sprintf("☑️ Image `%d` approved by %s, %d of %d approvals", img.ID, githubLink(staff.Login), img.Approvals.Votes, img.Approvals.Quorum))
+	}
+
+	if action == "approve" {
+		return reply(fmt.Sprintf("✅ Image `%d` approved by %s", img.ID, githubLink(staff.Login)))
+	}
+
+	return reply(fmt.Sprintf("❌ Image `%d` rejected by %s", img.ID, githubLink(staff.Login)))
+}
+
+func commandBan(login string, admin *utils.User, reason string) *discordgo.InteractionResponse {
+	user, err := database.GetUserFromLogin(login)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("No registered developer named `%s`.", login))
+	}
+
+	if user.ID == admin.ID {
+		return ephemeral("You cannot ban yourself.")
+	}
+
+	user, err = moderation.Ban(user.ID, admin, reason)
+	if err != nil {
+		log.Error("Failed to ban user %s from Discord: %s", login, err.Error())
+		return ephemeral(fmt.Sprintf("Failed to ban `%s`.", login))
+	}
+
+	return reply(fmt.Sprintf("🔨 %s banned by %s", githubLink(user.Login), githubLink(admin.Login)))
+}
+
+func commandLookup(login string) *discordgo.InteractionResponse {
+	user, err := database.GetUserFromLogin(login)
+	if err != nil {
+		user, err = database.GetAliasUser(login)
+		if err != nil {
+			return ephemeral(fmt.Sprintf("No registered developer named `%s`.", login))
+		}
+	}
+
+	var roles []string
+	if user.IsAdmin {
+		roles = append(roles, "admin")
+	}
+	if user.IsStaff {
+		roles = append(roles, "staff")
+	}
+	if user.Verified {
+		roles = append(roles, "verified")
+	}
+	if user.Banned {
+		roles = append(roles, "banned")
+	}
+	if len(roles) <= 0 {
+		roles = append(roles, "none")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (`%d`)\n", githubLink(user.Login), user.ID)
+	fmt.Fprintf(&b, "Status: %s\n", strings.Join(roles, ", "))
+	fmt.Fprintf(&b, "Joined: <t:%d:D>\n", user.Created.Unix())
+
+	img, err := database.GetImageForUser(user.ID)
+	if err != nil {
+		b.WriteString("Branding: none\n")
+	} else if img.Pending {
+		fmt.Fprintf(&b, "Branding: `%d` pending review ([image](%s))\n", img.ID, img.ImageURL)
+	} else {
+		fmt.Fprintf(&b, "Branding: `%d` live since <t:%d:D> ([image](%s))\n", img.ID, img.Created.Unix(), img.ImageURL)
+	}
+
+	if aliases, err := database.ListAliasesForUser(user.ID); err == nil && len(aliases) > 0 {
+		names := make([]string, 0, len(aliases))
+		for _, a := range aliases {
+			names = append(names, a.Alias)
+		}
+
+		fmt.Fprintf(&b, "Aliases: %s\n", strings.Join(names, ", "))
+	}
+
+	return ephemeral(b.String())
+}
+
+// runs a /branding subcommand with the same permission checks as the dashboard
+func handleCommand(i *discordgo.Interaction) *discordgo.InteractionResponse {
+	data := i.ApplicationCommandData()
+	if data.Name != brandingCommand.Name || len(data.Options) <= 0 {
+		return ephemeral("Unknown command.")
+	}
+
+	actor, err := getActor(i)
+	if err != nil {
+		log.Warn("Refused Discord command by %s: %s", interactionUser(i).ID, err.Error())
+		return ephemeral("Your Discord account is not linked to a staff account.")
+	}
+
+	sub := data.Options[0]
+
+	var reason string
+	if opt := optionValue(sub, "reason"); opt != nil {
+		reason = strings.TrimSpace(opt.StringValue())
+	}
+
+	if sub.Name == "ban" {
+		if !actor.IsAdmin {
+			return ephemeral(moderation.ErrNotAdmin.Error())
+		}
+	} else if !moderation.IsStaff(actor) {
+		return ephemeral(moderation.ErrNotStaff.Error())
+	}
+
+	switch sub.Name {
+	case "pending":
+		return commandPending()
+
+	case "approve", "reject":
+		id := optionValue(sub, "id")
+		if id == nil {
+			return ephemeral("Missing image ID.")
+		}
+
+		return commandModerate(sub.Name, id.IntValue(), actor, reason)
+
+	case "ban", "lookup":
+		login := optionValue(sub, "login")
+		if login == nil || strings.TrimSpace(login.StringValue()) == "" {
+			return ephemeral("Missing login.")
+		}
+
+		if sub.Name == "ban" {
+			return commandBan(strings.TrimSpace(login.StringValue()), actor, reason)
+		}
+
+		return commandLookup(strings.TrimSpace(login.StringValue()))
+
+	default:
+		return ephemeral("Unknown command.")
+	}
+}
@@ -0,0 +1,200 @@
+// Package interactions handles Discord interactions used by staff to moderate from Discord, over the HTTP endpoint or the optional bot.
+package interactions
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"service/database"
+	"service/discord"
+	"service/log"
+	"service/moderation"
+	"service/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// application public key set by DISCORD_PUBLIC_KEY, interactions are refused while it is missing
+var PublicKey ed25519.PublicKey
+
+// oldest signed timestamp accepted, so a captured request can't be replayed later
+const maxInteractionAge = 5 * time.Minute
+
+// whether a signature timestamp in unix seconds is recent enough, allowing for some clock skew either way
+func fresh(timestamp string, now time.Time) bool {
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := now.Sub(time.Unix(secs, 0))
+
+	return age <= maxInteractionAge && age >= -maxInteractionAge
+}
+
+// checks the Ed25519 signature Discord sends over the timestamp and raw body
+func Verify(key ed25519.PublicKey, signature string, timestamp string, body []byte) bool {
+	if len(key) != ed25519.PublicKeySize {
+		return false
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	msg := make([]byte, 0, len(timestamp)+len(body))
+	msg = append(msg, timestamp...)
+	msg = append(msg, body...)
+
+	return ed25519.Verify(key, msg, sig)
+}
+
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+
+	return i.User
+}
+
+// reply only the invoking user sees
+func ephemeral(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}
+}
+
+// runs an approve or reject button press and edits the submission message to show the outcome
+func handleComponent(i *discordgo.Interaction) *discordgo.InteractionResponse {
+	action, imgId, version, ok := discord.ParseModerationCustomID(i.MessageComponentData().CustomID)
+	if !ok {
+		return ephemeral("Unknown action.")
+	}
+
+	staff, err := getStaff(i)
+	if err != nil {
+		log.Warn("Refused Discord moderation by %s: %s", interactionUser(i).ID, err.Error())
+		return ephemeral("Your Discord account is not linked to a staff account.")
+	}
+
+	var img *utils.Img
+	if action == discord.ActionApprove {
+		img, err = moderation.Approve(imgId, version, staff, "")
+	} else {
+		img, err = moderation.Reject(imgId, version, staff, "")
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return ephemeral("This submission was changed or is being reviewed by someone else.")
+	} else if errors.Is(err, database.ErrAlreadyVoted) {
+		return ephemeral("You already approved this submission, it needs another staff member.")
+	} else if errors.Is(err, database.ErrWindowOver) {
+		return ephemeral("This scheduled branding's window has already ended.")
+	} else if err != nil {
+		log.Error("Failed to %s img %d from Discord: %s", action, imgId, err.Error())
+		return ephemeral("Failed to " + action + " the submission, it may already have been handled.")
+	}
+
+	// the buttons stay for the next reviewer
+	if action == discord.ActionApprove && img.Pending {
+		return ephemeral(fmt.Sprintf("Approval recorded, %d of %d approvals.", img.Approvals.Votes, img.Approvals.Quorum))
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	if i.Message != nil {
+		embeds = i.Message.Embeds
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     discord.ModerationOutcome(embeds, action, staff),
+			Components: []discordgo.MessageComponent{},
+		},
+	}
+}
+
+func handleInteraction(i *discordgo.Interaction) *discordgo.InteractionResponse {
+	switch i.Type {
+	case discordgo.InteractionPing:
+		return &discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong}
+
+	case discordgo.InteractionMessageComponent:
+		return handleComponent(i)
+
+	case discordgo.InteractionApplicationCommand:
+		return handleCommand(i)
+
+	default:
+		return ephemeral("Unsupported interaction.")
+	}
+}
+
+// answers interactions Discord posts to the interactions endpoint URL
+func serveInteractions(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		timestamp := r.Header.Get("X-Signature-Timestamp")
+		if !Verify(PublicKey, r.Header.Get("X-Signature-Ed25519"), timestamp, body) {
+			log.Warn("Rejected Discord interaction with invalid signature")
+			http.Error(w, "Invalid request signature", http.StatusUnauthorized)
+			return
+		}
+
+		if !fresh(timestamp, time.Now()) {
+			log.Warn("Rejected Discord interaction signed at %s", timestamp)
+			http.Error(w, "Request timestamp too old", http.StatusUnauthorized)
+			return
+		}
+
+		var i discordgo.Interaction
+		if err := json.Unmarshal(body, &i); err != nil {
+			log.Error("Failed to decode interaction: %s", err.Error())
+			http.Error(w, "Invalid interaction", http.StatusBadRequest)
+			return
+		}
+
+		header.Set("Content-Type", "application/json")
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(handleInteraction(&i)); err != nil {
+			log.Error("Failed to encode response: %s", err.Error())
+			return
+		}
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func init() {
+	if key := os.Getenv("DISCORD_PUBLIC_KEY"); key != "" {
+		decoded, err := hex.DecodeString(key)
+		if err != nil || len(decoded) != ed25519.PublicKeySize {
+			log.Error("DISCORD_PUBLIC_KEY is not a valid Ed25519 public key")
+		} else {
+			PublicKey = ed25519.PublicKey(decoded)
+		}
+	}
+
+	http.HandleFunc("/discord/interactions", serveInteractions)
+}
@@ -0,0 +1,213 @@
+package interactions
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// generates an application key pair and installs its public half for the duration of a test
+func testKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	prev := PublicKey
+	PublicKey = pub
+	t.Cleanup(func() { PublicKey = prev })
+
+	return priv
+}
+
+// posts a body to the interactions endpoint the way Discord signs it
+func post(t *testing.T, key ed25519.PrivateKey, timestamp string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	sig := ed25519.Sign(key, []byte(timestamp+body))
+
+	r := httptest.NewRequest(http.MethodPost, "/discord/interactions", strings.NewReader(body))
+	r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(sig))
+	r.Header.Set("X-Signature-Timestamp", timestamp)
+
+	w := httptest.NewRecorder()
+	serveInteractions(w, r)
+
+	return w
+}
+
+func now() string {
+	return strconv.FormatInt(time.Now().Unix(), 10)
+}
+
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) *discordgo.InteractionResponse {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var res discordgo.InteractionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	return &res
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	body := []byte(`{"type":1}`)
+	sig := hex.EncodeToString(ed25519.Sign(priv, append([]byte("1700000000"), body...)))
+
+	tests := []struct {
+		name      string
+		key       ed25519.PublicKey
+		signature string
+		timestamp string
+		body      []byte
+		want      bool
+	}{
+		{name: "valid", key: pub, signature: sig, timestamp: "1700000000", body: body, want: true},
+		{name: "other key", key: other, signature: sig, timestamp: "1700000000", body: body},
+		{name: "missing key", key: nil, signature: sig, timestamp: "1700000000", body: body},
+		{name: "tampered body", key: pub, signature: sig, timestamp: "1700000000", body: []byte(`{"type":2}`)},
+		{name: "tampered timestamp", key: pub, signature: sig, timestamp: "1700000001", body: body},
+		{name: "not hex", key: pub, signature: "zz" + sig[2:], timestamp: "1700000000", body: body},
+		{name: "truncated", key: pub, signature: sig[:64], timestamp: "1700000000", body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.key, tt.signature, tt.timestamp, tt.body); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFresh(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		want      bool
+	}{
+		{name: "just signed", timestamp: "1700000000", want: true},
+		{name: "within the window", timestamp: "1699999760", want: true},
+		{name: "replayed later", timestamp: "1699999000"},
+		{name: "far in the future", timestamp: "1700001000"},
+		{name: "not a number", timestamp: "yesterday"},
+		{name: "missing", timestamp: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fresh(tt.timestamp, at); got != tt.want {
+				t.Errorf("fresh(%q) = %v, want %v", tt.timestamp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeInteractionsRejectsBadRequests(t *testing.T) {
+	key := testKey(t)
+	_, stranger, _ := ed25519.GenerateKey(nil)
+
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name   string
+		key    ed25519.PrivateKey
+		stamp  string
+		status int
+	}{
+		{name: "signed by another key", key: stranger, stamp: now(), status: http.StatusUnauthorized},
+		{name: "replayed signature", key: key, stamp: stale, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := post(t, tt.key, tt.stamp, `{"type":1}`); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/discord/interactions", nil)
+	w := httptest.NewRecorder()
+	serveInteractions(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", w.Code)
+	}
+}
+
+func TestServeInteractionsPing(t *testing.T) {
+	key := testKey(t)
+
+	res := decodeResponse(t, post(t, key, now(), `{"id":"1","application_id":"2","type":1,"token":"t","version":1}`))
+	if res.Type != discordgo.InteractionResponsePong {
+		t.Errorf("response type = %d, want PONG", res.Type)
+	}
+}
+
+func TestServeInteractionsComponents(t *testing.T) {
+	key := testKey(t)
+
+	component := func(customId string) string {
+		return `{"id":"1","application_id":"2","type":3,"token":"t","version":1,` +
+			`"member":{"user":{"id":"42","username":"staff"},"roles":[]},` +
+			`"data":{"custom_id":"` + customId + `","component_type":2}}`
+	}
+
+	tests := []struct {
+		name     string
+		customId string
+		want     string
+	}{
+		// an unlinked Discord account never reaches moderation
+		{name: "approve", customId: "branding:approve:7:3", want: "not linked to a staff account"},
+		{name: "reject", customId: "branding:reject:7:3", want: "not linked to a staff account"},
+		{name: "approve without version", customId: "branding:approve:7", want: "not linked to a staff account"},
+		{name: "unknown action", customId: "branding:ban:7:3", want: "Unknown action"},
+		{name: "foreign button", customId: "other:approve:7", want: "Unknown action"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := decodeResponse(t, post(t, key, now(), component(tt.customId)))
+
+			if res.Type != discordgo.InteractionResponseChannelMessageWithSource || res.Data == nil {
+				t.Fatalf("response = %+v, want an ephemeral message", res)
+			}
+
+			if res.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+				t.Errorf("response is not ephemeral")
+			}
+
+			if !strings.Contains(res.Data.Content, tt.want) {
+				t.Errorf("content = %q, want it to mention %q", res.Data.Content, tt.want)
+			}
+		})
+	}
+}
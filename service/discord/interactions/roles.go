@@ -0,0 +1,89 @@
+package interactions
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"service/database"
+	"service/moderation"
+	"service/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// guild role IDs granting staff, set by DISCORD_STAFF_ROLES
+var staffRoles []string
+
+// guild role IDs granting admin, set by DISCORD_ADMIN_ROLES
+var adminRoles []string
+
+func parseRoles(env string) []string {
+	var out []string
+	for role := range strings.SplitSeq(os.Getenv(env), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			out = append(out, role)
+		}
+	}
+
+	return out
+}
+
+func hasRole(member *discordgo.Member, roles []string) bool {
+	if member == nil {
+		return false
+	}
+
+	for _, role := range member.Roles {
+		if slices.Contains(roles, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// account linked to the Discord user behind an interaction, with staff or admin granted by their guild roles
+func getActor(i *discordgo.Interaction) (*utils.User, error) {
+	du := interactionUser(i)
+	if du == nil {
+		return nil, errors.New("interaction has no user")
+	}
+
+	user, err := database.GetDiscordUser(du.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy so the cached user is left untouched
+	actor := *user
+	if hasRole(i.Member, adminRoles) {
+		actor.IsAdmin = true
+	}
+
+	if hasRole(i.Member, staffRoles) {
+		actor.IsStaff = true
+	}
+
+	return &actor, nil
+}
+
+// staff account behind an interaction
+func getStaff(i *discordgo.Interaction) (*utils.User, error) {
+	staff, err := getActor(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if !moderation.IsStaff(staff) {
+		return nil, moderation.ErrNotStaff
+	}
+
+	return staff, nil
+}
+
+func init() {
+	staffRoles = parseRoles("DISCORD_STAFF_ROLES")
+	adminRoles = parseRoles("DISCORD_ADMIN_ROLES")
+}
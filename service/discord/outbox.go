@@ -0,0 +1,185 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// webhooks outbox messages are delivered to
+const (
+	channelPublic = "public"
+	channelStaff  = "staff"
+)
+
+const (
+	outboxInterval   = 5 * time.Second
+	outboxBatch      = 25
+	outboxBaseDelay  = 10 * time.Second
+	outboxMaxDelay   = 1 * time.Hour
+	outboxMaxAttempt = 8
+)
+
+// Queued webhook message, stored without components since those can't be decoded back
+type outboxPayload struct {
+	Params          *discordgo.WebhookParams `json:"params"`
+	ModerateImage   uint64                   `json:"moderate_image,omitempty"`   // Image to attach moderation buttons for
+	ModerateVersion uint64                   `json:"moderate_version,omitempty"` // Image version the buttons decide on
+}
+
+var (
+	outboxWake = make(chan struct{}, 1)
+	outboxStop = make(chan struct{})
+	outboxDone = make(chan struct{})
+	outboxOnce sync.Once
+)
+
+func enqueue(channel string, payload *outboxPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	id, err := database.EnqueueOutbox(channel, data)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Queued Discord message %d for the %s webhook", id, channel)
+
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func retryDelay(attempts uint) time.Duration {
+	delay := outboxBaseDelay << attempts
+	if delay <= 0 || delay > outboxMaxDelay {
+		return outboxMaxDelay
+	}
+
+	return delay
+}
+
+func deliver(msg *utils.OutboxMessage) error {
+	var payload outboxPayload
+	if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+		return err
+	}
+
+	if payload.ModerateImage != 0 {
+		payload.Params.Components = moderationComponents(payload.ModerateImage, payload.ModerateVersion)
+	}
+
+	s, id, token, err := getSession(msg.Channel == channelStaff)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WebhookExecute(id, token, true, payload.Params)
+	return err
+}
+
+// attempts a message once, then records whether it was sent, should be retried, or is dead
+func processOutbox(msg *utils.OutboxMessage) {
+	err := deliver(msg)
+	if err == nil {
+		if err := database.MarkOutboxSent(msg.ID); err != nil {
+			log.Error("Failed to mark Discord message %d as sent: %s", msg.ID, err.Error())
+		}
+
+		return
+	}
+
+	var rateLimit *discordgo.RateLimitError
+	var restErr *discordgo.RESTError
+
+	switch {
+	case errors.As(err, &rateLimit):
+		log.Warn("Discord rate limited message %d for %s", msg.ID, rateLimit.RetryAfter)
+		err = database.MarkOutboxRetry(msg.ID, time.Now().Add(rateLimit.RetryAfter), false, err.Error())
+
+	case errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode < http.StatusInternalServerError:
+		// the request itself is bad, so retrying won't help
+		log.Error("Discord refused message %d: %s", msg.ID, err.Error())
+		err = database.MarkOutboxDead(msg.ID, err.Error())
+
+	case msg.Attempts+1 >= outboxMaxAttempt:
+		log.Error("Giving up on Discord message %d: %s", msg.ID, err.Error())
+		err = database.MarkOutboxDead(msg.ID, err.Error())
+
+	default:
+		delay := retryDelay(msg.Attempts)
+		log.Warn("Failed to send Discord message %d, retrying in %s: %s", msg.ID, delay, err.Error())
+		err = database.MarkOutboxRetry(msg.ID, time.Now().Add(delay), true, err.Error())
+	}
+
+	if err != nil {
+		log.Error("Failed to update Discord message %d: %s", msg.ID, err.Error())
+	}
+}
+
+// sends every due message, reporting how many were attempted
+func flushOutbox() int {
+	msgs, err := database.ListDueOutbox(outboxBatch)
+	if err != nil {
+		log.Error("Failed to list Discord outbox: %s", err.Error())
+		return 0
+	}
+
+	for _, msg := range msgs {
+		processOutbox(msg)
+	}
+
+	return len(msgs)
+}
+
+func runOutbox() {
+	defer close(outboxDone)
+
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-outboxStop:
+			return
+		case <-ticker.C:
+		case <-outboxWake:
+		}
+
+		for flushOutbox() >= outboxBatch {
+		}
+	}
+}
+
+// stops the outbox worker after sending whatever is due, or once the context ends
+func DrainOutbox(ctx context.Context) {
+	outboxOnce.Do(func() {
+		close(outboxStop)
+	})
+
+	select {
+	case <-outboxDone:
+	case <-ctx.Done():
+		log.Warn("Discord outbox worker did not stop in time")
+		return
+	}
+
+	for ctx.Err() == nil && flushOutbox() > 0 {
+	}
+
+	log.Print("Discord outbox drained")
+}
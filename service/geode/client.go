@@ -0,0 +1,171 @@
+package geode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"service/log"
+
+	"github.com/patrickmn/go-cache"
+)
+
+const DefaultBaseURL = "https://api.geode-sdk.org"
+
+var ErrModNotFound = errors.New("mod not found")
+
+// Geode index API client with response caching
+type Client struct {
+	BaseURL string        // API root, without the version prefix
+	HTTP    *http.Client  // Client used for requests
+	Retries int           // Extra attempts after a failed request
+	Backoff time.Duration // Delay before the first retry, doubled on each one
+
+	mods    *cache.Cache // mod ID to mod
+	missing *cache.Cache // mod IDs the index answered 404 for
+}
+
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: timeout},
+		Retries: 2,
+		Backoff: 500 * time.Millisecond,
+		mods:    cache.New(24*time.Hour, 1*time.Hour),
+		missing: cache.New(10*time.Minute, 10*time.Minute),
+	}
+}
+
+// client for the index set by GEODE_API_URL and GEODE_API_TIMEOUT
+var Default *Client
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// performs a GET against the index, retrying network errors and server failures
+func (c *Client) get(path string, out any) error {
+	reqUrl := c.BaseURL + path
+
+	var lastErr error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			delay := c.Backoff << (attempt - 1)
+			log.Debug("Retrying %s in %s (attempt %d)", reqUrl, delay, attempt+1)
+			time.Sleep(delay)
+		}
+
+		resp, err := c.HTTP.Get(reqUrl)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch %s: %w", path, err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return ErrModNotFound
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			lastErr = fmt.Errorf("index API returned status %d", resp.StatusCode)
+			if retryable(resp.StatusCode) {
+				continue
+			}
+
+			return lastErr
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(out)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode index API response: %w", err)
+		}
+
+		return nil
+	}
+
+	return lastErr
+}
+
+// fetches a mod from the index, caching both hits and misses
+func (c *Client) GetMod(modID string) (*Mod, error) {
+	if modID == "" {
+		return nil, fmt.Errorf("no mod id provided")
+	}
+
+	if cached, found := c.mods.Get(modID); found {
+		mod := cached.(Mod)
+		return &mod, nil
+	}
+
+	if _, found := c.missing.Get(modID); found {
+		return nil, ErrModNotFound
+	}
+
+	var res Response[Mod]
+	err := c.get(fmt.Sprintf("/v1/mods/%s", url.PathEscape(modID)), &res)
+	if errors.Is(err, ErrModNotFound) {
+		c.missing.Set(modID, true, cache.DefaultExpiration)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	c.mods.Set(modID, res.Payload, cache.DefaultExpiration)
+
+	return &res.Payload, nil
+}
+
+// returns a mod only if an earlier lookup cached it, never calling the index
+func (c *Client) Cached(modID string) (*Mod, bool) {
+	cached, found := c.mods.Get(modID)
+	if !found {
+		return nil, false
+	}
+
+	mod := cached.(Mod)
+	return &mod, true
+}
+
+// drops cached results for a mod
+func (c *Client) Forget(modID string) {
+	c.mods.Delete(modID)
+	c.missing.Delete(modID)
+}
+
+func GetMod(modID string) (*Mod, error) {
+	return Default.GetMod(modID)
+}
+
+func Cached(modID string) (*Mod, bool) {
+	return Default.Cached(modID)
+}
+
+func init() {
+	baseURL := DefaultBaseURL
+	if val := os.Getenv("GEODE_API_URL"); val != "" {
+		baseURL = val
+	}
+
+	timeout := 10 * time.Second
+	if val := os.Getenv("GEODE_API_TIMEOUT"); val != "" {
+		secs, err := strconv.Atoi(val)
+		if err == nil {
+			timeout = time.Duration(secs) * time.Second
+		} else {
+			log.Warn("Invalid GEODE_API_TIMEOUT %s, using %s", val, timeout)
+		}
+	}
+
+	Default = NewClient(baseURL, timeout)
+}
@@ -0,0 +1,172 @@
+package geode_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"service/geode"
+	"service/geode/geodetest"
+)
+
+var testMod = geode.Mod{
+	ID:            "dev.example",
+	Repository:    "https://github.com/example/mod",
+	DownloadCount: 1234,
+	Developers: []geode.ModDeveloper{
+		{ID: 1, Username: "owner", DisplayName: "Owner", IsOwner: true},
+		{ID: 2, Username: "helper", DisplayName: "Helper"},
+	},
+	Versions: []geode.ModVersion{
+		{Name: "Example", Version: "v1.2.0", ModID: "dev.example", GD: geode.ModGD{Win: "2.2074", MacArm: "2.2074"}},
+	},
+	Tags:  []string{"utility"},
+	Links: geode.ModLinks{Source: "https://github.com/example/mod"},
+}
+
+func TestGetModRetriesServerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		wantErr  bool
+		requests int
+	}{
+		{name: "no failures", failures: 0, requests: 1},
+		{name: "recovers within retries", failures: 2, requests: 3},
+		{name: "gives up after retries", failures: 3, wantErr: true, requests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := geodetest.NewServer(testMod)
+			defer srv.Close()
+
+			srv.FailNext(tt.failures)
+
+			mod, err := srv.Client().GetMod(testMod.ID)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetMod succeeded after %d failures, want error", tt.failures)
+				}
+			} else if err != nil {
+				t.Fatalf("GetMod: %v", err)
+			} else if mod.ID != testMod.ID {
+				t.Errorf("GetMod returned %q, want %q", mod.ID, testMod.ID)
+			}
+
+			if got := srv.Requests(); got != tt.requests {
+				t.Errorf("server saw %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestGetModCachesMisses(t *testing.T) {
+	srv := geodetest.NewServer()
+	defer srv.Close()
+
+	client := srv.Client()
+
+	for range 2 {
+		if _, err := client.GetMod(testMod.ID); !errors.Is(err, geode.ErrModNotFound) {
+			t.Fatalf("GetMod error = %v, want ErrModNotFound", err)
+		}
+	}
+
+	if got := srv.Requests(); got != 1 {
+		t.Errorf("server saw %d requests, want the second miss served from cache", got)
+	}
+
+	// stays missing until forgotten, even once the index has it
+	srv.SetMod(testMod)
+	if _, err := client.GetMod(testMod.ID); !errors.Is(err, geode.ErrModNotFound) {
+		t.Fatalf("GetMod error = %v, want cached ErrModNotFound", err)
+	}
+
+	client.Forget(testMod.ID)
+	if _, err := client.GetMod(testMod.ID); err != nil {
+		t.Fatalf("GetMod after Forget: %v", err)
+	}
+}
+
+func TestGetModDecodesPayload(t *testing.T) {
+	srv := geodetest.NewServer(testMod)
+	defer srv.Close()
+
+	client := srv.Client()
+
+	mod, err := client.GetMod(testMod.ID)
+	if err != nil {
+		t.Fatalf("GetMod: %v", err)
+	}
+
+	if mod.DownloadCount != testMod.DownloadCount || mod.Repository != testMod.Repository {
+		t.Errorf("GetMod = %+v, want fields of %+v", mod, testMod)
+	}
+
+	if len(mod.Developers) != 2 || mod.Developers[0].Username != "owner" || !mod.Developers[0].IsOwner || mod.Developers[1].IsOwner {
+		t.Errorf("developers = %+v, want owner then helper", mod.Developers)
+	}
+
+	if len(mod.Versions) != 1 || mod.Versions[0].GD.MacArm != "2.2074" {
+		t.Errorf("versions = %+v, want one with mac-arm support", mod.Versions)
+	}
+
+	if owner, err := mod.Links.SourceOwner(); err != nil || owner != "example" {
+		t.Errorf("SourceOwner = %q, %v, want example", owner, err)
+	}
+
+	// served from cache the second time
+	if _, err := client.GetMod(testMod.ID); err != nil {
+		t.Fatalf("cached GetMod: %v", err)
+	}
+
+	if got := srv.Requests(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestCachedNeverCallsIndex(t *testing.T) {
+	srv := geodetest.NewServer(testMod)
+	defer srv.Close()
+
+	client := srv.Client()
+
+	if _, found := client.Cached(testMod.ID); found {
+		t.Fatal("Cached found a mod that was never fetched")
+	}
+
+	if _, err := client.GetMod(testMod.ID); err != nil {
+		t.Fatalf("GetMod: %v", err)
+	}
+
+	if mod, found := client.Cached(testMod.ID); !found || mod.ID != testMod.ID {
+		t.Errorf("Cached = %v, %t, want %q", mod, found, testMod.ID)
+	}
+
+	if got := srv.Requests(); got != 1 {
+		t.Errorf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestValidModID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"dev.example", true},
+		{"some_dev.my-mod", true},
+		{"", false},
+		{"nodot", false},
+		{"Dev.Example", false},
+		{"dev.example.extra", false},
+		{"../etc.passwd", false},
+		{strings.Repeat("a", 40) + "." + strings.Repeat("b", 40), false},
+	}
+
+	for _, tt := range tests {
+		if got := geode.ValidModID(tt.id); got != tt.want {
+			t.Errorf("ValidModID(%q) = %t, want %t", tt.id, got, tt.want)
+		}
+	}
+}
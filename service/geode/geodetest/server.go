@@ -0,0 +1,100 @@
+// Package geodetest provides an in-process fake of the Geode index API.
+package geodetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"service/geode"
+)
+
+// Fake index serving a fixed set of mods
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	mods     map[string]geode.Mod
+	failures int
+	requests int
+}
+
+func NewServer(mods ...geode.Mod) *Server {
+	s := &Server{mods: make(map[string]geode.Mod)}
+	for _, mod := range mods {
+		s.mods[mod.ID] = mod
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+
+	if s.failures > 0 {
+		s.failures--
+		s.mu.Unlock()
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	modID, ok := strings.CutPrefix(r.URL.Path, "/v1/mods/")
+	mod, found := s.mods[modID]
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !ok || !found || r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(geode.Response[*geode.Mod]{Error: "Mod not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(geode.Response[geode.Mod]{Payload: mod})
+}
+
+// adds or replaces a mod
+func (s *Server) SetMod(mod geode.Mod) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mods[mod.ID] = mod
+}
+
+func (s *Server) RemoveMod(modID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mods, modID)
+}
+
+// makes the next n requests fail with a server error
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = n
+}
+
+// number of requests served so far
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// client pointed at the fake index, retrying without delay
+func (s *Server) Client() *geode.Client {
+	c := geode.NewClient(s.URL, 5*time.Second)
+	c.Backoff = 0
+
+	return c
+}
@@ -0,0 +1,113 @@
+package geode
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// shape of a mod ID the index accepts, developer and name separated by a dot
+var modIDPattern = regexp.MustCompile(`^[a-z0-9_-]+\.[a-z0-9_-]+$`)
+
+// whether a mod ID could exist in the index, without asking it
+func ValidModID(modID string) bool {
+	return len(modID) <= 64 && modIDPattern.MatchString(modID)
+}
+
+// Links published with a mod
+type ModLinks struct {
+	Community string `json:"community"` // Community server invite
+	Homepage  string `json:"homepage"`  // Mod homepage
+	Source    string `json:"source"`    // Source code repository
+}
+
+// GitHub account owning the mod's source repository
+func (l ModLinks) SourceOwner() (string, error) {
+	u, err := url.Parse(l.Source)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "github.com") {
+		return "", fmt.Errorf("not a GitHub repo URL: %s", l.Source)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) <= 0 || parts[0] == "" {
+		return "", fmt.Errorf("invalid GitHub repo URL: %s", l.Source)
+	}
+
+	return parts[0], nil
+}
+
+// Developer listed on a mod
+type ModDeveloper struct {
+	ID          uint64 `json:"id"`           // Geode index developer ID
+	Username    string `json:"username"`     // Geode developer name
+	DisplayName string `json:"display_name"` // Name shown in the index
+	IsOwner     bool   `json:"is_owner"`     // Owns the mod
+}
+
+// Geometry Dash versions a mod version supports, by platform
+type ModGD struct {
+	Win       string `json:"win,omitempty"`
+	Android   string `json:"android,omitempty"`
+	Android32 string `json:"android32,omitempty"`
+	Android64 string `json:"android64,omitempty"`
+	Mac       string `json:"mac,omitempty"`
+	MacIntel  string `json:"mac-intel,omitempty"`
+	MacArm    string `json:"mac-arm,omitempty"`
+	IOS       string `json:"ios,omitempty"`
+}
+
+// Dependency or incompatibility of a mod version
+type ModRelation struct {
+	ModID      string `json:"mod_id"`     // Related mod ID
+	Version    string `json:"version"`    // Version range
+	Importance string `json:"importance"` // How strongly it applies
+}
+
+// Published version of a mod
+type ModVersion struct {
+	Name              string        `json:"name"`              // Display name
+	Description       string        `json:"description"`       // Short description
+	Version           string        `json:"version"`           // Version string
+	DownloadLink      string        `json:"download_link"`     // Package download URL
+	DownloadCount     uint64        `json:"download_count"`    // Downloads of this version
+	Hash              string        `json:"hash"`              // Package hash
+	Geode             string        `json:"geode"`             // Required Geode version
+	EarlyLoad         bool          `json:"early_load"`        // Loads before the game
+	API               bool          `json:"api"`               // Provides an API for other mods
+	ModID             string        `json:"mod_id"`            // Mod this version belongs to
+	GD                ModGD         `json:"gd"`                // Supported game versions
+	Status            string        `json:"status"`            // Index review status
+	Tags              []string      `json:"tags"`              // Tags of this version
+	Dependencies      []ModRelation `json:"dependencies"`      // Required mods
+	Incompatibilities []ModRelation `json:"incompatibilities"` // Conflicting mods
+	Created           time.Time     `json:"created_at"`        // First published
+	Updated           time.Time     `json:"updated_at"`        // Last updated
+}
+
+// Mod as listed in the Geode index
+type Mod struct {
+	ID            string         `json:"id"`             // Mod ID
+	Repository    string         `json:"repository"`     // Repository URL
+	Featured      bool           `json:"featured"`       // Featured in the index
+	DownloadCount uint64         `json:"download_count"` // Downloads across all versions
+	Developers    []ModDeveloper `json:"developers"`     // Listed developers
+	Versions      []ModVersion   `json:"versions"`       // Published versions, newest first
+	Tags          []string       `json:"tags"`           // Index tags
+	About         string         `json:"about"`          // About page
+	Changelog     string         `json:"changelog"`      // Changelog
+	Links         ModLinks       `json:"links"`          // Published links
+	Created       time.Time      `json:"created_at"`     // First published
+	Updated       time.Time      `json:"updated_at"`     // Last updated
+}
+
+// Response envelope of the Geode index API
+type Response[T any] struct {
+	Error   string `json:"error"`
+	Payload T      `json:"payload"`
+}
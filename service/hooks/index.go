@@ -0,0 +1,245 @@
+// Package hooks delivers signed branding events to admin-managed webhook subscriptions.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+// request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Branding-Event"
+	HeaderDelivery  = "X-Branding-Delivery"
+	HeaderTimestamp = "X-Branding-Timestamp"
+	HeaderSignature = "X-Branding-Signature"
+)
+
+const (
+	workerInterval = 5 * time.Second
+	workerBatch    = 25
+	baseDelay      = 10 * time.Second
+	maxDelay       = 1 * time.Hour
+	maxAttempts    = 8
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+var (
+	wake     = make(chan struct{}, 1)
+	stop     = make(chan struct{})
+	done     = make(chan struct{})
+	stopOnce sync.Once
+)
+
+// HMAC-SHA256 of the timestamp and body joined by a dot, as sent in the signature header
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func enqueue(sub *utils.WebhookSubscription, event string, payload []byte) error {
+	id, err := database.EnqueueDelivery(sub.ID, event, payload)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Queued %s delivery %d for webhook %d", event, id, sub.ID)
+
+	return nil
+}
+
+// queues an event for every subscription that wants it, failures are only logged so they never hold up the action itself
+func Emit(event string, data any) {
+	payload, err := json.Marshal(utils.WebhookEvent{
+		Event:   event,
+		Created: time.Now().UTC(),
+		Data:    data,
+	})
+	if err != nil {
+		log.Error("Failed to encode %s event: %s", event, err.Error())
+		return
+	}
+
+	subs, err := database.ListSubscriptions()
+	if err != nil {
+		log.Error("Failed to list webhook subscriptions: %s", err.Error())
+		return
+	}
+
+	for _, sub := range subs {
+		if sub.Wants(event) {
+			if err := enqueue(sub, event, payload); err != nil {
+				log.Error("Failed to queue %s for webhook %d: %s", event, sub.ID, err.Error())
+			}
+		}
+	}
+
+	notify()
+}
+
+// queues a test delivery to a single subscription
+func Ping(sub *utils.WebhookSubscription, actor *utils.User) error {
+	payload, err := json.Marshal(utils.WebhookEvent{
+		Event:   utils.EventPing,
+		Created: time.Now().UTC(),
+		Data:    utils.BrandingEventData{Actor: actor},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := enqueue(sub, utils.EventPing, payload); err != nil {
+		return err
+	}
+
+	notify()
+
+	return nil
+}
+
+func retryDelay(attempts uint) time.Duration {
+	delay := baseDelay << attempts
+	if delay <= 0 || delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+// posts a delivery once, returning the response status if there was one
+func send(d *utils.WebhookDelivery, sub *utils.WebhookSubscription) (int, error) {
+	body := []byte(d.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ModDevBranding-Webhooks")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func process(d *utils.WebhookDelivery) {
+	sub, err := database.GetSubscription(d.SubscriptionID)
+	if err != nil {
+		log.Error("Failed to get webhook %d: %s", d.SubscriptionID, err.Error())
+		return
+	}
+
+	code, err := send(d, sub)
+	if err == nil {
+		err = database.MarkDeliverySent(d.ID, code)
+	} else if d.Attempts+1 >= maxAttempts {
+		log.Error("Giving up on webhook delivery %d: %s", d.ID, err.Error())
+		err = database.MarkDeliveryDead(d.ID, code, err.Error())
+	} else {
+		delay := retryDelay(d.Attempts)
+		log.Warn("Failed webhook delivery %d, retrying in %s: %s", d.ID, delay, err.Error())
+		err = database.MarkDeliveryRetry(d.ID, code, time.Now().Add(delay), err.Error())
+	}
+
+	if err != nil {
+		log.Error("Failed to update webhook delivery %d: %s", d.ID, err.Error())
+	}
+}
+
+func flush() int {
+	deliveries, err := database.ListDueDeliveries(workerBatch)
+	if err != nil {
+		log.Error("Failed to list webhook deliveries: %s", err.Error())
+		return 0
+	}
+
+	for _, d := range deliveries {
+		process(d)
+	}
+
+	return len(deliveries)
+}
+
+func run() {
+	defer close(done)
+
+	ticker := time.NewTicker(workerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+
+		for flush() >= workerBatch {
+		}
+	}
+}
+
+// stops the delivery worker after sending whatever is due, or once the context ends
+func Drain(ctx context.Context) {
+	stopOnce.Do(func() {
+		close(stop)
+	})
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("Webhook worker did not stop in time")
+		return
+	}
+
+	for ctx.Err() == nil && flush() > 0 {
+	}
+
+	log.Print("Webhook deliveries drained")
+}
+
+// starts delivering webhook events, until Drain stops it
+func Start() {
+	go run()
+}
@@ -0,0 +1,178 @@
+// Package impressions counts branding serves per developer, mod and day in memory, flushing them to the database in batches.
+package impressions
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"service/database"
+	"service/geode"
+	"service/log"
+	"service/utils"
+)
+
+// distinct counters held before flushing early
+const maxPending = 10000
+
+// how often counters are written to the database, set by IMPRESSION_FLUSH
+var flushInterval = 30 * time.Second
+
+type counter struct {
+	userId uint64
+	modId  string
+	day    string
+}
+
+var (
+	mu      sync.Mutex
+	pending = make(map[counter]uint64)
+
+	wake     = make(chan struct{}, 1)
+	stop     = make(chan struct{})
+	done     = make(chan struct{})
+	stopOnce sync.Once
+)
+
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// counts one serve of a developer's branding, requested for a mod or for none
+func Record(userId uint64, modId string) {
+	// anything that can't be a mod ID is counted without a mod
+	if !geode.ValidModID(modId) {
+		modId = ""
+	}
+
+	c := counter{userId: userId, modId: modId, day: time.Now().UTC().Format(time.DateOnly)}
+
+	mu.Lock()
+	// made-up mod IDs can't grow the map without bound
+	if _, found := pending[c]; !found && len(pending) >= maxPending {
+		c.modId = ""
+	}
+
+	pending[c]++
+	full := len(pending) >= maxPending
+	mu.Unlock()
+
+	if full {
+		notify()
+	}
+}
+
+// whether the developer is listed on a mod the index already answered for while serving, anything else is counted without a mod
+func modListsDeveloper(modId string, userId uint64) bool {
+	// only cached mods, so client-sent IDs never turn into index requests
+	mod, found := geode.Cached(modId)
+	if !found {
+		return false
+	}
+
+	user, err := database.GetUser(userId)
+	if err != nil {
+		return false
+	}
+
+	return database.ModListsUser(mod, user)
+}
+
+// writes every pending counter, putting them back if the database refuses them
+func flush() int {
+	mu.Lock()
+	batch := pending
+	pending = make(map[counter]uint64)
+	mu.Unlock()
+
+	if len(batch) == 0 {
+		return 0
+	}
+
+	// checked here rather than when counted so serving stays fast
+	merged := make(map[counter]uint64, len(batch))
+	for c, n := range batch {
+		if c.modId != "" && !modListsDeveloper(c.modId, c.userId) {
+			c.modId = ""
+		}
+
+		merged[c] += n
+	}
+
+	counts := make([]*utils.ImpressionCount, 0, len(merged))
+	for c, n := range merged {
+		counts = append(counts, &utils.ImpressionCount{UserID: c.userId, ModID: c.modId, Day: c.day, Count: n})
+	}
+
+	if err := database.AddImpressions(counts); err != nil {
+		log.Error("Failed to flush %d impression counters: %s", len(counts), err.Error())
+
+		mu.Lock()
+		for c, n := range merged {
+			pending[c] += n
+		}
+		mu.Unlock()
+
+		return 0
+	}
+
+	log.Debug("Flushed %d impression counters", len(counts))
+
+	return len(counts)
+}
+
+func run() {
+	defer close(done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+
+		flush()
+	}
+}
+
+// starts flushing counters in the background, until Drain stops it
+func Start() {
+	go run()
+}
+
+// stops the flush worker and writes whatever was counted, or gives up once the context ends
+func Drain(ctx context.Context) {
+	stopOnce.Do(func() {
+		close(stop)
+	})
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("Impression worker did not stop in time")
+		return
+	}
+
+	flush()
+
+	log.Print("Impressions flushed")
+}
+
+func init() {
+	if interval := os.Getenv("IMPRESSION_FLUSH"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			log.Warn("Invalid IMPRESSION_FLUSH %s, using %s", interval, flushInterval)
+		} else {
+			flushInterval = d
+		}
+	}
+}
@@ -14,7 +14,14 @@ import (
 	"service/access"
 	_ "service/api"
 	_ "service/brand"
+	"service/discord"
+	"service/discord/interactions"
+	"service/hooks"
+	"service/impressions"
 	"service/log"
+	"service/moderation"
+	"service/screening"
+	"service/utils"
 
 	"github.com/patrickmn/go-cache"
 	"golang.org/x/time/rate"
@@ -22,16 +29,6 @@ import (
 
 var visitors = cache.New(15*time.Minute, 30*time.Minute)
 
-func getClientIP(r *http.Request) string {
-	if cf := r.Header.Get("CF-Connecting-IP"); cf != "" {
-		return cf
-	}
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return strings.Split(xff, ",")[0]
-	}
-	return strings.Split(r.RemoteAddr, ":")[0]
-}
-
 func getVisitor(ip string) *rate.Limiter {
 	if val, found := visitors.Get(ip); found {
 		return val.(*rate.Limiter)
@@ -44,7 +41,7 @@ func getVisitor(ip string) *rate.Limiter {
 
 func rateLimitMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := getClientIP(r)
+		ip := access.GetClientIP(r)
 		limiter := getVisitor(ip)
 
 		if !limiter.Allow() {
@@ -93,16 +90,21 @@ func main() {
 
 	log.Debug("Starting image handler...")
 	http.HandleFunc("/cdn/", func(w http.ResponseWriter, r *http.Request) {
-		header := w.Header()
-
 		requestedPath := strings.TrimPrefix(r.URL.Path, "/cdn/")
-		fullPath := filepath.Join("..", "cdn", requestedPath)
-
-		header.Set("Content-Type", "image/webp")
 
-		http.ServeFile(w, r, fullPath)
+		// the stored bytes decide the type, files keep whatever format was uploaded
+		utils.ServeImageFile(w, r, filepath.Join("..", "cdn", requestedPath))
 	})
 
+	// background workers, stopped again by the shutdown sequence
+	log.Debug("Starting background workers...")
+	discord.StartOutbox()
+	hooks.Start()
+	interactions.StartBot()
+	screening.StartBackfill()
+	moderation.StartSchedule()
+	impressions.Start()
+
 	log.Debug("Starting handlers...")
 
 	go func() {
@@ -133,4 +135,11 @@ func main() {
 	} else {
 		log.Print("Server stopped")
 	}
+
+	// stopped first so it can't queue events the workers below have already drained
+	moderation.StopSchedule(ctx)
+	interactions.Close()
+	discord.DrainOutbox(ctx)
+	hooks.Drain(ctx)
+	impressions.Drain(ctx)
 }
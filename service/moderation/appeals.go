@@ -0,0 +1,72 @@
+package moderation
+
+import (
+	"service/database"
+	"service/hooks"
+	"service/log"
+	"service/screening"
+	"service/utils"
+)
+
+// files an appeal for a user, banned users can only contest the ban itself
+func SubmitAppeal(user *utils.User, auditId uint64, message string) (*utils.Appeal, error) {
+	if user.Banned {
+		entry, err := database.GetAudit(auditId)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Action != utils.AuditUserBan {
+			return nil, database.ErrNotAppealable
+		}
+	}
+
+	appeal, err := database.CreateAppeal(user.ID, auditId, message, utils.NewAudit(user, utils.AuditAppealSubmit, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("User %s appealed %s (audit entry %d) as appeal %d", user.Login, appeal.Action, auditId, appeal.ID)
+
+	return appeal, nil
+}
+
+// moves an appeal along on behalf of a staff member, only admins can lift bans
+func DecideAppeal(id uint64, status string, staff *utils.User, note string) (*utils.Appeal, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	appeal, err := database.GetAppeal(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == utils.AppealGranted && appeal.Action == utils.AuditUserBan && !staff.IsAdmin {
+		return nil, ErrNotAdmin
+	}
+
+	appeal, err = database.UpdateAppeal(id, status, note, screening.HashFile, utils.NewAudit(staff, utils.AuditAppealUpdate, note))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Staff %s moved appeal %d to %s", staff.Login, appeal.ID, appeal.Status)
+
+	if status == utils.AppealGranted {
+		if appeal.Action == utils.AuditUserBan {
+			user, err := database.GetUser(appeal.UserID)
+			if err != nil {
+				log.Warn("Failed to get unbanned user %d: %s", appeal.UserID, err.Error())
+			} else {
+				hooks.Emit(utils.EventUserUnbanned, utils.BrandingEventData{User: user, Actor: staff})
+			}
+		}
+
+		if img, err := database.GetImage(appeal.ImageID); err == nil && !img.Pending {
+			emit(utils.EventBrandingRestored, img, staff)
+		}
+	}
+
+	return appeal, nil
+}
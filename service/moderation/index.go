@@ -0,0 +1,344 @@
+// Package moderation holds the branding moderation actions shared by the dashboard and Discord.
+package moderation
+
+import (
+	"errors"
+	"fmt"
+
+	"service/database"
+	"service/discord"
+	"service/hooks"
+	"service/log"
+	"service/screening"
+	"service/utils"
+)
+
+var ErrNotStaff = errors.New("user is not admin or staff")
+
+var ErrNotAdmin = errors.New("user is not admin")
+
+var ErrNotOwner = errors.New("user does not own the image")
+
+var ErrNoReason = errors.New("a reason is required")
+
+func IsStaff(user *utils.User) bool {
+	return user != nil && (user.IsAdmin || user.IsStaff)
+}
+
+// sends a branding event to webhook subscribers
+func emit(event string, img *utils.Img, actor *utils.User) {
+	owner, err := database.GetUser(img.UserID)
+	if err != nil {
+		log.Warn("Failed to get owner of img %d: %s", img.ID, err.Error())
+	}
+
+	hooks.Emit(event, utils.BrandingEventData{Image: img, User: owner, Actor: actor})
+}
+
+// announces a new submission to staff and subscribers, review is false when it gets approved right away
+func Submitted(img *utils.Img, user *utils.User, review bool) {
+	err := discord.WebhookStaffSubmit(img, review)
+	if err != nil {
+		log.Warn(err.Error())
+	}
+
+	emit(utils.EventBrandingSubmitted, img, user)
+}
+
+// announces a published image, staff is nil when it was auto-approved
+func Published(img *utils.Img, staff *utils.User) {
+	err := discord.WebhookAccept(img, staff)
+	if err != nil {
+		log.Warn(err.Error())
+	}
+
+	// the scheduler announces scheduled brandings once their window starts
+	if img.Scheduled() {
+		return
+	}
+
+	emit(utils.EventBrandingApproved, img, staff)
+}
+
+// approves a pending image on behalf of a staff member, version 0 approves whatever version is pending, returning it still pending when more approvals are needed
+func Approve(imgId uint64, version uint64, staff *utils.User, reason string) (*utils.Img, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	// admins count towards the quorum like anyone else unless they override it
+	img, err := database.ApproveImage(imgId, version, false, utils.NewAudit(staff, utils.AuditImageApprove, reason))
+	if err != nil {
+		return nil, err
+	}
+
+	if img.Pending {
+		log.Info("Staff %s approved img %d, %d of %d approvals", staff.Login, img.ID, img.Approvals.Votes, img.Approvals.Quorum)
+		return img, nil
+	}
+
+	log.Info("Staff %s approved img %d", staff.Login, img.ID)
+
+	Published(img, staff)
+
+	return img, nil
+}
+
+func remove(imgId uint64, version uint64, actor *utils.User, event string, action string, reason string) (*utils.Img, error) {
+	ownerId, err := database.GetImageOwnerId(imgId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsStaff(actor) && (actor == nil || (ownerId != actor.ID && !ownsOrgImage(imgId, actor))) {
+		return nil, ErrNotOwner
+	}
+
+	del := database.DeleteImage
+	if action == utils.AuditImageReject {
+		del = database.RejectImage
+	}
+
+	img, err := del(imgId, version, utils.NewAudit(actor, action, reason))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("User %s deleted img %d of user %d", actor.Login, img.ID, img.UserID)
+
+	emit(event, img, actor)
+
+	return img, nil
+}
+
+// publishes a pending image on behalf of an admin without waiting for the approval quorum, audited apart from regular approvals
+func Override(imgId uint64, version uint64, admin *utils.User, reason string) (*utils.Img, error) {
+	if admin == nil || !admin.IsAdmin {
+		return nil, ErrNotAdmin
+	}
+
+	if reason == "" {
+		return nil, ErrNoReason
+	}
+
+	img, err := database.ApproveImage(imgId, version, true, utils.NewAudit(admin, utils.AuditImageOverride, reason))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Admin %s published img %d skipping the approval quorum: %s", admin.Login, img.ID, reason)
+
+	Published(img, admin)
+
+	return img, nil
+}
+
+// deletes an image on behalf of its owner or a staff member
+func Delete(imgId uint64, version uint64, actor *utils.User, reason string) (*utils.Img, error) {
+	return remove(imgId, version, actor, utils.EventBrandingDeleted, utils.AuditImageDelete, reason)
+}
+
+// deletes a submission on behalf of a staff member
+func Reject(imgId uint64, version uint64, staff *utils.User, reason string) (*utils.Img, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	return remove(imgId, version, staff, utils.EventBrandingRejected, utils.AuditImageReject, reason)
+}
+
+// hashes of an image, from the database or its stored file
+func imageHashes(img *utils.Img) (uint64, uint64, error) {
+	if img.PHash != nil && img.DHash != nil {
+		return *img.PHash, *img.DHash, nil
+	}
+
+	return screening.HashStoredImage(img)
+}
+
+// adds hashes to the blocklist so the image can't be uploaded again from another account
+func block(img *utils.Img, phash uint64, dhash uint64, actor *utils.User, reason string) {
+	id, err := database.AddBlockedHash(phash, dhash, reason, img.ID, utils.NewAudit(actor, utils.AuditBlocklistAdd, reason))
+	if err != nil {
+		log.Error("Failed to block hashes of img %d: %s", img.ID, err.Error())
+		return
+	}
+
+	log.Info("Blocked hashes of img %d as entry %d", img.ID, id)
+}
+
+// deletes an image for abuse on behalf of a staff member and blocks it from being uploaded again
+func RemoveAbuse(imgId uint64, version uint64, staff *utils.User, reason string) (*utils.Img, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	img, err := database.GetImage(imgId)
+	if err != nil {
+		return nil, err
+	}
+
+	// hashed before the file goes away with the image
+	phash, dhash, hashErr := imageHashes(img)
+
+	img, err = remove(imgId, version, staff, utils.EventBrandingDeleted, utils.AuditImageDelete, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if hashErr != nil {
+		log.Warn("Could not block img %d, failed to hash it: %s", img.ID, hashErr.Error())
+	} else {
+		block(img, phash, dhash, staff, reason)
+	}
+
+	return img, nil
+}
+
+// bans a user and removes their branding on behalf of an admin
+func Ban(userId uint64, admin *utils.User, reason string) (*utils.User, error) {
+	if admin == nil || !admin.IsAdmin {
+		return nil, ErrNotAdmin
+	}
+
+	img, imgErr := database.GetImageForUser(userId)
+
+	var phash, dhash uint64
+	var hashErr error
+	if imgErr == nil {
+		phash, dhash, hashErr = imageHashes(img)
+	}
+
+	user, err := database.BanUser(userId, utils.NewAudit(admin, utils.AuditUserBan, reason))
+	if err != nil {
+		return nil, err
+	}
+
+	if imgErr == nil {
+		if hashErr != nil {
+			log.Warn("Could not block img %d of banned user, failed to hash it: %s", img.ID, hashErr.Error())
+		} else {
+			why := fmt.Sprintf("owner %s was banned", user.Login)
+			if reason != "" {
+				why += ": " + reason
+			}
+
+			block(img, phash, dhash, admin, why)
+		}
+	}
+
+	log.Info("Admin %s banned user %s", admin.Login, user.Login)
+
+	hooks.Emit(utils.EventUserBanned, utils.BrandingEventData{User: user, Actor: admin})
+
+	return user, nil
+}
+
+// alerts staff to a player report, only on the first and every fifth open report to keep the channel quiet
+func Reported(img *utils.Img, category string, count int) {
+	if count == 1 || count%5 == 0 {
+		err := discord.WebhookStaffReport(img, category, count)
+		if err != nil {
+			log.Warn(err.Error())
+		}
+	}
+
+	emit(utils.EventBrandingReported, img, nil)
+}
+
+// takes a live image down on behalf of a staff member, sending it back to the review queue
+func Unpublish(imgId uint64, staff *utils.User, reason string) (*utils.Img, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	img, err := database.UnpublishImage(imgId, utils.NewAudit(staff, utils.AuditImageUnpublish, reason))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Staff %s unpublished img %d", staff.Login, img.ID)
+
+	emit(utils.EventBrandingUnpublished, img, staff)
+
+	return img, nil
+}
+
+// closes the open reports against an image on behalf of a staff member, leaving it live
+func DismissReports(imgId uint64, staff *utils.User, reason string) error {
+	if !IsStaff(staff) {
+		return ErrNotStaff
+	}
+
+	if err := database.DismissReports(imgId, utils.NewAudit(staff, utils.AuditReportDismiss, reason)); err != nil {
+		return err
+	}
+
+	log.Info("Staff %s dismissed reports on img %d", staff.Login, imgId)
+
+	return nil
+}
+
+// applies one action to many images on behalf of a staff member, announcing them in one Discord summary
+func Bulk(action string, ids []uint64, staff *utils.User, reason string) ([]*utils.BulkResult, error) {
+	if !IsStaff(staff) {
+		return nil, ErrNotStaff
+	}
+
+	var auditAction, event string
+	switch action {
+	case utils.BulkApprove:
+		auditAction, event = utils.AuditImageApprove, utils.EventBrandingApproved
+	case utils.BulkReject:
+		auditAction, event = utils.AuditImageReject, utils.EventBrandingRejected
+	case utils.BulkDelete:
+		auditAction, event = utils.AuditImageDelete, utils.EventBrandingDeleted
+	default:
+		return nil, fmt.Errorf("unknown bulk action %s", action)
+	}
+
+	results, err := database.BulkModerate(action, ids, staff, auditAction, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]*utils.Img, 0, len(results))
+	published := make([]*utils.Img, 0, len(results))
+	for _, res := range results {
+		if !res.OK {
+			continue
+		}
+
+		applied = append(applied, res.Image)
+
+		// approvals short of the quorum publish nothing yet
+		if action == utils.BulkApprove && res.Image.Pending {
+			continue
+		}
+
+		published = append(published, res.Image)
+
+		// same as Published, the scheduler announces scheduled brandings once their window starts
+		if action == utils.BulkApprove && res.Image.Scheduled() {
+			continue
+		}
+
+		emit(event, res.Image, staff)
+	}
+
+	log.Info("Staff %s bulk %s %d of %d imgs", staff.Login, action, len(applied), len(results))
+
+	if len(applied) > 0 {
+		if err := discord.WebhookBulkSummary(action, staff, results); err != nil {
+			log.Warn(err.Error())
+		}
+
+		if action == utils.BulkApprove && len(published) > 0 {
+			if err := discord.WebhookAcceptBatch(published, staff); err != nil {
+				log.Warn(err.Error())
+			}
+		}
+	}
+
+	return results, nil
+}
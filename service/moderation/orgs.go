@@ -0,0 +1,21 @@
+package moderation
+
+import (
+	"service/database"
+	"service/utils"
+)
+
+// whether the user owns the organization an image is the branding of, any of its owners may take it down
+func ownsOrgImage(imgId uint64, user *utils.User) bool {
+	img, err := database.GetImage(imgId)
+	if err != nil || img.OrgID() == 0 {
+		return false
+	}
+
+	m, err := database.GetOrgMembership(img.OrgID(), user.ID)
+	if err != nil {
+		return false
+	}
+
+	return m.Role == utils.OrgRoleOwner
+}
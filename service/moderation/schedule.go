@@ -0,0 +1,118 @@
+package moderation
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+)
+
+// how often scheduled brandings are checked for a window starting or ending, set by SCHEDULE_INTERVAL
+var scheduleInterval = 1 * time.Minute
+
+var (
+	scheduleStop     = make(chan struct{})
+	scheduleDone     = make(chan struct{})
+	scheduleStopOnce sync.Once
+)
+
+// announces scheduled brandings whose window started or ended since the last check
+func advanceSchedules(now time.Time) {
+	imgs, err := database.ListDueSchedules(now)
+	if err != nil {
+		log.Error("Failed to list due scheduled brandings: %s", err.Error())
+		return
+	}
+
+	for _, img := range imgs {
+		from, to, event := img.Schedule, utils.ScheduleLive, utils.EventBrandingApproved
+		if !now.Before(*img.Ends) {
+			to, event = utils.ScheduleExpired, utils.EventBrandingExpired
+		}
+
+		// another instance may have gotten to it first
+		advanced, err := database.AdvanceSchedule(img.ID, from, to)
+		if err != nil {
+			log.Error("Failed to advance scheduled img %d to %s: %s", img.ID, to, err.Error())
+			continue
+		} else if !advanced {
+			continue
+		}
+
+		img.Schedule = to
+		log.Info("Scheduled img %d (%s) is now %s", img.ID, img.Slot, to)
+
+		emit(event, img, nil)
+	}
+}
+
+// removes scheduled brandings whose window ended at least an interval ago, their file is archived with the row's audit entry
+func pruneSchedules(now time.Time) {
+	imgs, err := database.ListEndedSchedules(now.Add(-scheduleInterval))
+	if err != nil {
+		log.Error("Failed to list ended scheduled brandings: %s", err.Error())
+		return
+	}
+
+	for _, img := range imgs {
+		// a claimed submission is left for the next check
+		if _, err := database.DeleteImage(img.ID, 0, utils.NewAudit(nil, utils.AuditImageExpire, "scheduled window ended")); err != nil {
+			log.Warn("Failed to remove ended scheduled img %d: %s", img.ID, err.Error())
+			continue
+		}
+
+		log.Info("Removed scheduled img %d (%s) after its window ended", img.ID, img.Slot)
+	}
+}
+
+func runSchedule() {
+	defer close(scheduleDone)
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-scheduleStop:
+			return
+		case now := <-ticker.C:
+			// pruned first, so a branding announced as expired stays expired until the next check at least
+			pruneSchedules(now)
+			advanceSchedules(now)
+		}
+	}
+}
+
+// starts checking scheduled brandings, until StopSchedule stops it
+func StartSchedule() {
+	go runSchedule()
+}
+
+// stops the scheduler, letting a check in progress finish or giving up once the context ends
+func StopSchedule(ctx context.Context) {
+	scheduleStopOnce.Do(func() {
+		close(scheduleStop)
+	})
+
+	select {
+	case <-scheduleDone:
+		log.Print("Scheduler stopped")
+	case <-ctx.Done():
+		log.Warn("Scheduler did not stop in time")
+	}
+}
+
+func init() {
+	if interval := os.Getenv("SCHEDULE_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			log.Warn("Invalid SCHEDULE_INTERVAL %s, using %s", interval, scheduleInterval)
+		} else {
+			scheduleInterval = d
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package screening
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+
+	"golang.org/x/image/draw"
+)
+
+// difference hash, one bit per horizontally adjacent pair in a 9x8 grayscale thumbnail
+func DHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := range 8 {
+		for x := range 8 {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y < gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// number of differing bits between two hashes
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// perceptual hash, the signs of the lowest 8x8 DCT frequencies of a 32x32 grayscale thumbnail against their median
+func PHash(img image.Image) uint64 {
+	gray := image.NewGray(image.Rect(0, 0, 32, 32))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var coeffs [64]float64
+	for u := range 8 {
+		for v := range 8 {
+			var sum float64
+			for y := range 32 {
+				for x := range 32 {
+					sum += dctTable[u][x] * dctTable[v][y] * float64(gray.GrayAt(x, y).Y)
+				}
+			}
+
+			coeffs[u*8+v] = sum
+		}
+	}
+
+	// the DC term only carries overall brightness
+	sorted := make([]float64, 63)
+	copy(sorted, coeffs[1:])
+	slices.Sort(sorted)
+	median := (sorted[31] + sorted[32]) / 2
+
+	var hash uint64
+	for _, c := range coeffs {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+
+	return hash
+}
+
+// DCT-II basis for the 8 lowest frequencies over 32 samples
+var dctTable = func() (table [8][32]float64) {
+	for u := range 8 {
+		for x := range 32 {
+			table[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / 64)
+		}
+	}
+
+	return table
+}()
+
+// perceptual and difference hashes of a stored branding file
+func HashStoredImage(stored *utils.Img) (uint64, uint64, error) {
+	return HashFile(filepath.Join("..", "cdn", stored.FileName()))
+}
+
+// perceptual and difference hashes of an image file anywhere on disk
+func HashFile(path string) (uint64, uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	img, _, _, err := decodeLimited(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return PHash(img), DHash(img), nil
+}
+
+// hashes images stored before hashes were kept, in the background
+func StartBackfill() {
+	go backfillHashes()
+}
+
+// hashes images stored before hashes were kept
+func backfillHashes() {
+	imgs, err := database.ListUnhashedImages()
+	if err != nil {
+		log.Error("Failed to list images without hashes: %s", err.Error())
+		return
+	}
+
+	hashed := 0
+	for _, img := range imgs {
+		phash, dhash, err := HashStoredImage(img)
+		if err != nil {
+			log.Debug("Failed to hash img %d: %s", img.ID, err.Error())
+			continue
+		}
+
+		if err := database.SetImageHashes(img.ID, phash, dhash); err != nil {
+			log.Error("Failed to store hashes of img %d: %s", img.ID, err.Error())
+			continue
+		}
+
+		hashed++
+	}
+
+	if hashed > 0 {
+		log.Info("Computed perceptual hashes for %d stored images", hashed)
+	}
+}
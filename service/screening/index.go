@@ -0,0 +1,247 @@
+// Package screening runs automatic checks on submitted branding before it reaches the review queue.
+package screening
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+
+	"service/log"
+	"service/utils"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// rules run on every submission when SCREEN_RULES is not set
+const defaultRuleChain = "valid,dimensions,blank,duplicate,blocklist"
+
+// rules rejecting a submission outright when SCREEN_HARD_FAIL is not set
+const defaultHardFail = "valid,blocklist"
+
+// highest risk score a submission can get
+const maxScore = 100
+
+// risk score holding verified users' submissions for review, set by SCREEN_HOLD_SCORE
+var holdScore = 50
+
+// Uploaded image being screened
+type Submission struct {
+	UserID uint64      // Submitting user
+	Data   []byte      // Raw upload
+	Hash   string      // SHA-256 of the upload
+	Image  image.Image // Decoded upload, nil if it could not be decoded or is too large
+	Size   image.Point // Declared width and height, zero if the header could not be read
+	Format string      // Decoded format
+	PHash  uint64      // Perceptual hash, set when decoded
+	DHash  uint64      // Difference hash, set when decoded
+}
+
+// checks a submission, returning the problems it found
+type rule func(s *Submission) []utils.ScreenFlag
+
+var rules = make(map[string]rule)
+
+// active rules in order
+var ruleChain []string
+
+// rules whose flags reject a submission
+var hardFail = make(map[string]bool)
+
+func registerRule(name string, r rule) {
+	rules[name] = r
+}
+
+func envInt(name string, fallback int) int {
+	if value := os.Getenv(name); value != "" {
+		n, err := strconv.Atoi(value)
+		if err == nil {
+			return n
+		}
+
+		log.Warn("Invalid %s %s, using %d", name, value, fallback)
+	}
+
+	return fallback
+}
+
+func envFloat(name string, fallback float64) float64 {
+	if value := os.Getenv(name); value != "" {
+		n, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return n
+		}
+
+		log.Warn("Invalid %s %s, using %g", name, value, fallback)
+	}
+
+	return fallback
+}
+
+func splitList(value string) []string {
+	var out []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// reads the declared size first so a small file claiming huge dimensions is never decoded
+func decodeLimited(data []byte) (image.Image, image.Config, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, cfg, "", err
+	}
+
+	if cfg.Width > maxSize || cfg.Height > maxSize {
+		return nil, cfg, format, fmt.Errorf("image is %dx%d, larger than %dx%d", cfg.Width, cfg.Height, maxSize, maxSize)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, cfg, format, err
+	}
+
+	return img, cfg, format, nil
+}
+
+// runs every active rule on an upload
+func Screen(userId uint64, data []byte) *utils.Screening {
+	sum := sha256.Sum256(data)
+	s := &Submission{
+		UserID: userId,
+		Data:   data,
+		Hash:   hex.EncodeToString(sum[:]),
+	}
+
+	img, cfg, format, err := decodeLimited(data)
+	if format != "" {
+		s.Size = image.Pt(cfg.Width, cfg.Height)
+		s.Format = format
+	}
+	if err == nil {
+		s.Image = img
+	}
+
+	out := &utils.Screening{Flags: make([]utils.ScreenFlag, 0)}
+	if s.Image != nil {
+		phash, dhash := PHash(s.Image), DHash(s.Image)
+		out.PHash, out.DHash = &phash, &dhash
+		s.PHash, s.DHash = phash, dhash
+	}
+	for _, name := range ruleChain {
+		for _, flag := range rules[name](s) {
+			flag.Rule = name
+			flag.Hard = hardFail[name]
+
+			out.Flags = append(out.Flags, flag)
+			out.Score += flag.Score
+
+			if flag.Hard {
+				out.Rejected = true
+			}
+		}
+	}
+
+	out.Score = min(out.Score, maxScore)
+
+	return out
+}
+
+// whether a submission is risky enough to wait for staff even from a verified user
+func HoldsForReview(s *utils.Screening) bool {
+	return s.Score >= holdScore
+}
+
+// summary of the flags, for logs and error responses
+func Describe(s *utils.Screening) string {
+	messages := make([]string, 0, len(s.Flags))
+	for _, flag := range s.Flags {
+		messages = append(messages, flag.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// reads SHA-256 hashes one per line, skipping blank lines and # comments
+func loadBlocklist(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		out[strings.ToLower(strings.Fields(line)[0])] = true
+	}
+
+	return out, scanner.Err()
+}
+
+func init() {
+	registerRule("valid", ruleValid)
+	registerRule("dimensions", ruleDimensions)
+	registerRule("blank", ruleBlank)
+	registerRule("duplicate", ruleDuplicate)
+	registerRule("blocklist", ruleBlocklist)
+
+	chain := os.Getenv("SCREEN_RULES")
+	if chain == "" {
+		chain = defaultRuleChain
+	}
+
+	for _, name := range splitList(chain) {
+		if _, found := rules[name]; found {
+			ruleChain = append(ruleChain, name)
+		} else {
+			log.Warn("Unknown rule %s in SCREEN_RULES", name)
+		}
+	}
+
+	hard, found := os.LookupEnv("SCREEN_HARD_FAIL")
+	if !found {
+		hard = defaultHardFail
+	}
+
+	for _, name := range splitList(hard) {
+		hardFail[name] = true
+	}
+
+	holdScore = envInt("SCREEN_HOLD_SCORE", holdScore)
+	minSize = envInt("SCREEN_MIN_SIZE", minSize)
+	maxSize = envInt("SCREEN_MAX_SIZE", maxSize)
+	blankRatio = envFloat("SCREEN_BLANK_RATIO", blankRatio)
+	duplicateDistance = envInt("SCREEN_DUPLICATE_DISTANCE", duplicateDistance)
+	blocklistDistance = envInt("SCREEN_BLOCKLIST_DISTANCE", blocklistDistance)
+
+	if path := os.Getenv("SCREEN_BLOCKLIST"); path != "" {
+		list, err := loadBlocklist(path)
+		if err != nil {
+			log.Error("Failed to load screening blocklist: %s", err.Error())
+		} else {
+			blocklist = list
+			log.Info("Loaded %d blocked image hashes", len(list))
+		}
+	}
+
+	log.Debug("Screening submissions through %s", strings.Join(ruleChain, ", "))
+}
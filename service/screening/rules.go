@@ -0,0 +1,153 @@
+package screening
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"service/database"
+	"service/log"
+	"service/utils"
+
+	"golang.org/x/image/draw"
+)
+
+// smallest width and height accepted, set by SCREEN_MIN_SIZE
+var minSize = 64
+
+// largest width and height accepted, set by SCREEN_MAX_SIZE
+var maxSize = 4096
+
+// share of transparent or flat pixels making an image blank, set by SCREEN_BLANK_RATIO
+var blankRatio = 0.95
+
+// highest hash distance counted as a near-duplicate, set by SCREEN_DUPLICATE_DISTANCE
+var duplicateDistance = 4
+
+// SHA-256 hashes of known-bad uploads, loaded from SCREEN_BLOCKLIST
+var blocklist = make(map[string]bool)
+
+// highest distance of both hashes to a blocklist entry counted as a match, set by SCREEN_BLOCKLIST_DISTANCE
+var blocklistDistance = 8
+
+// side of the thumbnail blank images are measured on
+const blankSample = 64
+
+func ruleValid(s *Submission) []utils.ScreenFlag {
+	if s.Image != nil {
+		return nil
+	}
+
+	if s.Size.X > maxSize || s.Size.Y > maxSize {
+		return []utils.ScreenFlag{{Message: fmt.Sprintf("image is %dx%d, too large to read", s.Size.X, s.Size.Y), Score: maxScore}}
+	}
+
+	return []utils.ScreenFlag{{Message: "file is not a readable image", Score: maxScore}}
+}
+
+func ruleDimensions(s *Submission) []utils.ScreenFlag {
+	if s.Format == "" {
+		return nil
+	}
+
+	size := s.Size
+	if size.X < minSize || size.Y < minSize {
+		return []utils.ScreenFlag{{Message: fmt.Sprintf("image is %dx%d, smaller than %dx%d", size.X, size.Y, minSize, minSize), Score: 30}}
+	}
+
+	if size.X > maxSize || size.Y > maxSize {
+		return []utils.ScreenFlag{{Message: fmt.Sprintf("image is %dx%d, larger than %dx%d", size.X, size.Y, maxSize, maxSize), Score: 30}}
+	}
+
+	return nil
+}
+
+func ruleBlank(s *Submission) []utils.ScreenFlag {
+	if s.Image == nil {
+		return nil
+	}
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, blankSample, blankSample))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), s.Image, s.Image.Bounds(), draw.Src, nil)
+
+	total := float64(blankSample * blankSample)
+	transparent := 0
+	var sum, sumSq float64
+	for y := range blankSample {
+		for x := range blankSample {
+			c := thumb.NRGBAAt(x, y)
+			if c.A < 16 {
+				transparent++
+				continue
+			}
+
+			lum := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+			sum += lum
+			sumSq += lum * lum
+		}
+	}
+
+	if float64(transparent)/total >= blankRatio {
+		return []utils.ScreenFlag{{Message: fmt.Sprintf("image is %.0f%% transparent", 100*float64(transparent)/total), Score: 50}}
+	}
+
+	visible := total - float64(transparent)
+	mean := sum / visible
+	if math.Sqrt(max(sumSq/visible-mean*mean, 0)) < 2 {
+		return []utils.ScreenFlag{{Message: "image is a single flat color", Score: 50}}
+	}
+
+	return nil
+}
+
+func ruleDuplicate(s *Submission) []utils.ScreenFlag {
+	if s.Image == nil {
+		return nil
+	}
+
+	imgs, err := database.ListAllImages()
+	if err != nil {
+		return nil
+	}
+
+	for _, img := range imgs {
+		if img.UserID == s.UserID || img.DHash == nil {
+			continue
+		}
+
+		if Distance(s.DHash, *img.DHash) <= duplicateDistance {
+			owner := fmt.Sprintf("user %d", img.UserID)
+			if u, err := database.GetUser(img.UserID); err == nil {
+				owner = "@" + u.Login
+			}
+
+			return []utils.ScreenFlag{{Message: fmt.Sprintf("near-duplicate of the branding of %s (img %d)", owner, img.ID), Score: 60}}
+		}
+	}
+
+	return nil
+}
+
+func ruleBlocklist(s *Submission) []utils.ScreenFlag {
+	if blocklist[s.Hash] {
+		return []utils.ScreenFlag{{Message: "image matches a blocked upload", Score: maxScore}}
+	}
+
+	if s.Image == nil {
+		return nil
+	}
+
+	blocked, err := database.ListBlockedHashes()
+	if err != nil {
+		log.Error("Failed to list blocked hashes: %s", err.Error())
+		return nil
+	}
+
+	for _, b := range blocked {
+		if Distance(s.PHash, b.PHash) <= blocklistDistance && Distance(s.DHash, b.DHash) <= blocklistDistance {
+			return []utils.ScreenFlag{{Message: fmt.Sprintf("image resembles blocked image %d", b.ID), Score: maxScore}}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package utils
+
+import "time"
+
+// Database row for Geode developer name aliases
+type Alias struct {
+	Alias     string    `json:"alias"`      // Lowercase Geode developer name
+	UserID    uint64    `json:"user_id"`    // Owner GitHub user ID
+	ModID     string    `json:"mod_id"`     // Mod that proved the claim, empty if added by an admin
+	CreatedBy uint64    `json:"created_by"` // User who added the alias
+	Created   time.Time `json:"created_at"` // First created
+}
@@ -0,0 +1,69 @@
+package utils
+
+import "time"
+
+// Appeal states
+const (
+	AppealOpen    = "open"         // Waiting for staff
+	AppealReview  = "under_review" // Picked up by a staff member
+	AppealGranted = "granted"      // Action was reverted
+	AppealDenied  = "denied"       // Action stands
+)
+
+// states an appeal may move to from each state
+var appealTransitions = map[string][]string{
+	AppealOpen:   {AppealReview, AppealGranted, AppealDenied},
+	AppealReview: {AppealOpen, AppealGranted, AppealDenied},
+}
+
+// whether an appeal may move from one state to another
+func CanTransitionAppeal(from string, to string) bool {
+	for _, s := range appealTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// whether an appeal is closed for good
+func AppealClosed(status string) bool {
+	return status == AppealGranted || status == AppealDenied
+}
+
+// Moderation actions a user may appeal
+var AppealableActions = []string{
+	AuditUserBan,
+	AuditImageDelete,
+	AuditImageReject,
+	AuditImageUnpublish,
+}
+
+// Database row for an appeal against a moderation action
+type Appeal struct {
+	ID         uint64         `json:"id"`               // Appeal ID
+	UserID     uint64         `json:"user_id"`          // Appealing user
+	AuditID    uint64         `json:"audit_id"`         // Audit entry of the contested action
+	Action     string         `json:"action"`           // Contested action
+	ImageID    uint64         `json:"image_id"`         // Affected image, 0 if none
+	Message    string         `json:"message"`          // Why the user thinks the action was wrong
+	Status     string         `json:"status"`           // Appeal state
+	ReviewerID uint64         `json:"reviewer_id"`      // Staff member who last handled it, 0 if none
+	Created    time.Time      `json:"created_at"`       // Submitted at
+	Updated    time.Time      `json:"updated_at"`       // Last state change
+	Login      string         `json:"login,omitempty"`  // Appealing user's username
+	Events     []*AppealEvent `json:"events,omitempty"` // State history, oldest first
+	Reason     string         `json:"reason,omitempty"` // Reason given for the contested action
+	Banned     bool           `json:"banned,omitempty"` // Whether the appealing user is currently banned
+}
+
+// Recorded step in an appeal's history, visible to the appealing user
+type AppealEvent struct {
+	ID       uint64    `json:"id"`         // Event ID
+	AppealID uint64    `json:"appeal_id"`  // Appeal it belongs to
+	ActorID  uint64    `json:"actor_id"`   // Who made the change
+	Status   string    `json:"status"`     // State after the change
+	Note     string    `json:"note"`       // Note to the user
+	Created  time.Time `json:"created_at"` // Changed at
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Moderation and administrative actions recorded in the audit log
+const (
+	AuditImageSubmit    = "image.submit"    // Owner uploaded a new image
+	AuditImageApprove   = "image.approve"   // Image published by staff or auto-approval
+	AuditImageVote      = "image.vote"      // Staff approval recorded, short of the quorum
+	AuditImageOverride  = "image.override"  // Image published by an admin skipping the approval quorum
+	AuditImageScreened  = "image.screened"  // Upload rejected by pre-screening before it was stored
+	AuditImageReject    = "image.reject"    // Submission turned down by staff
+	AuditImageDelete    = "image.delete"    // Image deleted by its owner or staff
+	AuditImageUnpublish = "image.unpublish" // Live image sent back to review by staff
+	AuditImageRestore   = "image.restore"   // Removed or unpublished image put back live after an appeal
+	AuditImageExpire    = "image.expire"    // Scheduled image removed once its window ended
+	AuditReportDismiss  = "report.dismiss"  // Reports closed without action
+	AuditUserVerify     = "user.verify"     // User marked as trusted
+	AuditUserBan        = "user.ban"        // User banned and their branding removed
+	AuditUserUnban      = "user.unban"      // Ban lifted after an appeal
+	AuditAppealSubmit   = "appeal.submit"   // User contested a moderation action
+	AuditAppealUpdate   = "appeal.update"   // Appeal moved to another state by staff
+	AuditAliasCreate    = "alias.create"    // Developer name linked to a user
+	AuditAliasDelete    = "alias.delete"    // Developer name unlinked
+	AuditOrgCreate      = "org.create"      // Organization founded by a developer
+	AuditOrgDelete      = "org.delete"      // Organization and its branding removed
+	AuditOrgMemberAdd   = "org.member.add"  // User added to an organization
+	AuditOrgMemberDrop  = "org.member.drop" // User left or was removed from an organization
+	AuditOrgMemberRole  = "org.member.role" // Member promoted or demoted
+	AuditOrgOptIn       = "org.optin"       // Member started or stopped showing the team branding
+	AuditOrgModAdd      = "org.mod.add"     // Geode mod listed as proof of the team
+	AuditOrgModDrop     = "org.mod.drop"    // Geode mod no longer listed
+	AuditNoteCreate     = "note.create"     // Staff note written on a user
+	AuditNoteDelete     = "note.delete"     // Staff note removed
+	AuditDiscordLink    = "discord.link"    // Discord account linked to a user
+	AuditDiscordUnlink  = "discord.unlink"  // Discord account unlinked
+	AuditWebhookCreate  = "webhook.create"  // Outgoing webhook subscription added
+	AuditWebhookUpdate  = "webhook.update"  // Outgoing webhook subscription toggled
+	AuditWebhookDelete  = "webhook.delete"  // Outgoing webhook subscription removed
+	AuditOutboxRetry    = "outbox.retry"    // Dead-lettered Discord message requeued
+	AuditBlocklistAdd   = "blocklist.add"   // Image hash added to the blocklist
+	AuditBlocklistDrop  = "blocklist.drop"  // Image hash removed from the blocklist
+)
+
+// Database row for the audit log
+type AuditEntry struct {
+	ID          uint64          `json:"id"`              // Entry ID
+	ActorID     uint64          `json:"actor_id"`        // Who acted, 0 for the system
+	Action      string          `json:"action"`          // Action taken
+	TargetUser  uint64          `json:"target_user_id"`  // Affected user, 0 if none
+	TargetImage uint64          `json:"target_image_id"` // Affected image, 0 if none
+	Before      json.RawMessage `json:"before"`          // State before the action
+	After       json.RawMessage `json:"after"`           // State after the action
+	Reason      string          `json:"reason"`          // Reason given by the actor
+	Created     time.Time       `json:"created_at"`      // When it happened
+}
+
+// starts an audit entry for an action, actor is nil for the system
+func NewAudit(actor *User, action string, reason string) *AuditEntry {
+	entry := &AuditEntry{Action: action, Reason: reason}
+	if actor != nil {
+		entry.ActorID = actor.ID
+	}
+
+	return entry
+}
+
+// stores the state before and after the action, nil leaves a side empty
+func (a *AuditEntry) Record(before any, after any) {
+	a.Before = marshalState(before)
+	a.After = marshalState(after)
+}
+
+func marshalState(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+
+	return b
+}
+
+// Audit log query, zero fields match anything
+type AuditFilter struct {
+	ActorID     uint64
+	Action      string
+	TargetUser  uint64
+	TargetImage uint64
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
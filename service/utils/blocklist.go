@@ -0,0 +1,14 @@
+package utils
+
+import "time"
+
+// Database row for the perceptual hash blocklist
+type BlockedHash struct {
+	ID          uint64    `json:"id"`              // Entry ID
+	PHash       uint64    `json:"phash,string"`    // Perceptual hash of the blocked image
+	DHash       uint64    `json:"dhash,string"`    // Difference hash of the blocked image
+	Reason      string    `json:"reason"`          // Why it was blocked
+	SourceImage uint64    `json:"source_image_id"` // Image it was taken from, 0 if added by hand
+	CreatedBy   uint64    `json:"created_by"`      // Staff member who added it, 0 for the system
+	Created     time.Time `json:"created_at"`      // First created
+}
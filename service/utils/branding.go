@@ -0,0 +1,53 @@
+package utils
+
+import "time"
+
+// Branding availability for a developer
+const (
+	BrandingApproved = "approved" // Live and served by the image endpoint
+	BrandingPending  = "pending"  // Submitted but still under review
+	BrandingLegacy   = "legacy"   // Served from the legacy images repository
+	BrandingNone     = "none"     // No branding available
+	BrandingError    = "error"    // Lookup failed, worth retrying later
+)
+
+// How a requested developer was resolved to a registered user
+const (
+	ResolveLogin        = "login"         // Requested name is a registered login
+	ResolveAlias        = "alias"         // Requested name is a claimed Geode developer name
+	ResolveModDeveloper = "mod-developer" // Listed on the requested mod under the same name
+	ResolveModAlias     = "mod-alias"     // Listed on the requested mod under an alias of the same login
+	ResolveModSource    = "mod-source"    // Owns the requested mod's source repository
+	ResolveModOwner     = "mod-owner"     // No developer requested, so the mod owner
+	ResolveLegacy       = "legacy"        // Not registered, served from the legacy images repository
+)
+
+// Downloadable rendition of a branding image
+type BrandingImage struct {
+	Format string `json:"format"`          // Image format requested from the image endpoint
+	Scale  int    `json:"scale"`           // Scale factor of the rendition
+	Theme  string `json:"theme,omitempty"` // Popup theme the rendition is a variant for, empty for the default
+	URL    string `json:"url"`             // Image endpoint URL for this rendition
+	Width  int    `json:"width"`           // Width in pixels
+	Height int    `json:"height"`          // Height in pixels
+}
+
+// Branding metadata for a requested developer
+type Branding struct {
+	Developer string          `json:"developer"`       // Developer as requested
+	Mod       string          `json:"mod,omitempty"`   // Mod as requested
+	Login     string          `json:"login"`           // Resolved GitHub username
+	Status    string          `json:"status"`          // Availability of the branding
+	Resolved  string          `json:"resolved_by"`     // How the developer was resolved
+	Images    []BrandingImage `json:"images"`          // Available renditions
+	Hash      string          `json:"hash"`            // SHA-256 of the image contents
+	Updated   *time.Time      `json:"updated_at"`      // Last approved or submitted
+	Error     string          `json:"error,omitempty"` // Why the lookup failed, set with the error status
+}
+
+// Manifest entry for an approved developer branding
+type ManifestEntry struct {
+	Hash    string    `json:"hash"`       // SHA-256 of the image contents
+	URL     string    `json:"url"`        // Image endpoint URL
+	Updated time.Time `json:"updated_at"` // Last approved
+}
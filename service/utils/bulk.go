@@ -0,0 +1,16 @@
+package utils
+
+// Bulk moderation actions
+const (
+	BulkApprove = "approve" // Publish pending images
+	BulkReject  = "reject"  // Turn down pending submissions
+	BulkDelete  = "delete"  // Remove images, live or pending
+)
+
+// Outcome for one image of a bulk moderation request
+type BulkResult struct {
+	ImageID uint64 `json:"image_id"`        // Requested image ID
+	OK      bool   `json:"ok"`              // Whether the action was applied
+	Error   string `json:"error,omitempty"` // Why it was not
+	Image   *Img   `json:"image,omitempty"` // Image after the action, or as it was before a removal
+}
@@ -0,0 +1,43 @@
+package utils
+
+import "time"
+
+// Database row for a staff note on a user, never shown to the user
+type StaffNote struct {
+	ID          uint64    `json:"id"`           // Note ID
+	UserID      uint64    `json:"user_id"`      // User the note is about
+	AuthorID    uint64    `json:"author_id"`    // Staff member who wrote it
+	AuthorLogin string    `json:"author_login"` // Staff member username
+	Note        string    `json:"note"`         // Note text
+	Created     time.Time `json:"created_at"`   // Written at
+}
+
+// One submission by a user and what became of it, built from the audit log
+type SubmissionRecord struct {
+	ImageID   uint64     `json:"image_id"`             // Image ID, 0 if screening turned the upload away
+	Submitted time.Time  `json:"submitted_at"`         // Uploaded at
+	Outcome   string     `json:"outcome"`              // Audit action of the latest decision, empty while pending
+	Decided   *time.Time `json:"decided_at,omitempty"` // Latest decision at
+	DecidedBy uint64     `json:"decided_by"`           // Staff member behind the latest decision, 0 for the system
+	Reason    string     `json:"reason"`               // Reason given for the latest decision
+}
+
+// Everything staff know about a user's moderation history
+type Dossier struct {
+	User        *User               `json:"user"`         // User the dossier is about
+	Notes       []*StaffNote        `json:"notes"`        // Staff notes, newest first
+	Submissions []*SubmissionRecord `json:"submissions"`  // Submissions, newest first
+	Reports     []*Report           `json:"reports"`      // Player reports against their branding, newest first
+	RoleChanges []*AuditEntry       `json:"role_changes"` // Verifications, bans and unbans, newest first
+	Appeals     []*Appeal           `json:"appeals"`      // Appeals they filed, newest first
+}
+
+// Dossier counts shown next to a pending submission
+type DossierSummary struct {
+	Notes       int    `json:"notes"`       // Staff notes
+	LatestNote  string `json:"latest_note"` // Newest staff note
+	Submissions int    `json:"submissions"` // Past submissions
+	Rejected    int    `json:"rejected"`    // Submissions turned down by staff or screening
+	Removed     int    `json:"removed"`     // Live images removed or unpublished by staff
+	Reports     int    `json:"reports"`     // Player reports against their branding
+}
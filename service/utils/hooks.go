@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Branding events sent to webhook subscribers
+const (
+	EventPing                = "ping"                 // Test delivery to a single subscription
+	EventBrandingSubmitted   = "branding.submitted"   // New image awaiting review
+	EventBrandingApproved    = "branding.approved"    // Image published
+	EventBrandingRejected    = "branding.rejected"    // Submission turned down by staff
+	EventBrandingDeleted     = "branding.deleted"     // Image deleted by its owner or staff
+	EventBrandingUnpublished = "branding.unpublished" // Live image sent back to review by staff
+	EventBrandingReported    = "branding.reported"    // Player reported a live image
+	EventBrandingRestored    = "branding.restored"    // Image put back live after an appeal
+	EventBrandingExpired     = "branding.expired"     // Scheduled image's window ended
+	EventUserBanned          = "user.banned"          // User banned and their branding removed
+	EventUserUnbanned        = "user.unbanned"        // Ban lifted after an appeal
+)
+
+// Database row for outgoing webhook subscriptions
+type WebhookSubscription struct {
+	ID        uint64    `json:"id"`               // Subscription ID
+	URL       string    `json:"url"`              // Endpoint events are posted to
+	Secret    string    `json:"secret,omitempty"` // HMAC signing key, only shown when created
+	Events    string    `json:"events"`           // Comma-separated events, or * for all
+	Active    bool      `json:"active"`           // Receiving events
+	CreatedBy uint64    `json:"created_by"`       // Admin who added it
+	Created   time.Time `json:"created_at"`       // First created
+}
+
+// whether the subscription wants an event
+func (s *WebhookSubscription) Wants(event string) bool {
+	if !s.Active {
+		return false
+	}
+
+	events := strings.Split(s.Events, ",")
+	for i := range events {
+		events[i] = strings.TrimSpace(events[i])
+	}
+
+	return slices.Contains(events, "*") || slices.Contains(events, event)
+}
+
+// Database row for a single event delivery to a subscription
+type WebhookDelivery struct {
+	ID             uint64     `json:"id"`              // Delivery ID
+	SubscriptionID uint64     `json:"subscription_id"` // Receiving subscription
+	Event          string     `json:"event"`           // Event name
+	Payload        string     `json:"payload"`         // Encoded request body
+	Status         string     `json:"status"`          // Delivery state, same as the Discord outbox
+	Attempts       uint       `json:"attempts"`        // Failed deliveries so far
+	ResponseCode   int        `json:"response_code"`   // Status of the last response
+	LastError      string     `json:"last_error"`      // Error of the last failed delivery
+	NextAttempt    time.Time  `json:"next_attempt_at"` // Earliest next delivery
+	Created        time.Time  `json:"created_at"`      // First queued
+	Delivered      *time.Time `json:"delivered_at"`    // Delivered at
+}
+
+// Body of an outgoing webhook request
+type WebhookEvent struct {
+	Event   string    `json:"event"`      // Event name
+	Created time.Time `json:"created_at"` // When it happened
+	Data    any       `json:"data"`       // Event details
+}
+
+// Details of a branding or user event
+type BrandingEventData struct {
+	Image *Img  `json:"image,omitempty"` // Affected image
+	User  *User `json:"user"`            // Affected user
+	Actor *User `json:"actor"`           // Who caused it, null for the system
+}
@@ -1,13 +1,180 @@
 package utils
 
-import "time"
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Slot of the branding served when nothing else applies
+const SlotDefault = ""
+
+// prefix of slots holding scheduled brandings
+const scheduledSlotPrefix = "scheduled-"
+
+// prefix of slots holding theme variants
+const themeSlotPrefix = "theme-"
+
+// prefix of slots holding an organization's branding
+const orgSlotPrefix = "org-"
+
+// slot of an organization's branding, owned by the organization rather than its submitter
+func OrgSlot(orgId uint64) string {
+	return fmt.Sprintf("%s%d", orgSlotPrefix, orgId)
+}
+
+// Themes of the Geode mod popup a branding can have a variant for
+const (
+	ThemeLight = "light"
+	ThemeDark  = "dark"
+)
+
+var Themes = []string{ThemeLight, ThemeDark}
+
+// slot of the variant shown with a theme
+func ThemeSlot(theme string) string {
+	return themeSlotPrefix + theme
+}
+
+// Lifecycle of a scheduled branding, advanced by the scheduler
+const (
+	ScheduleWaiting = "waiting" // Window has not started, or not been announced yet
+	ScheduleLive    = "live"    // Window started and was announced
+	ScheduleExpired = "expired" // Window ended
+)
+
+// slot of a scheduled branding with the developer's chosen name
+func ScheduledSlot(name string) string {
+	return scheduledSlotPrefix + name
+}
 
 // Database row for images listing
 type Img struct {
-	ID       uint64    `json:"id"`         // Image ID
-	UserID   uint64    `json:"user_id"`    // Owner GitHub user ID
-	ImageURL string    `json:"image_url"`  // URL to the image image
-	Created  time.Time `json:"created_at"` // First created
-	Pending  bool      `json:"pending"`    // Under review
-	Login    string    `json:"login"`      // Owner branding image
+	ID        uint64          `json:"id"`                  // Image ID
+	UserID    uint64          `json:"user_id"`             // Owner GitHub user ID
+	ImageURL  string          `json:"image_url"`           // URL to the image image
+	Created   time.Time       `json:"created_at"`          // First created
+	Pending   bool            `json:"pending"`             // Under review
+	Legacy    bool            `json:"legacy"`              // Imported from the legacy images repository
+	Version   uint64          `json:"version"`             // Bumped on every change, for optimistic concurrency
+	Risk      int             `json:"risk_score"`          // Pre-screening risk score
+	Flags     []ScreenFlag    `json:"flags,omitempty"`     // Pre-screening problems found
+	PHash     *uint64         `json:"-"`                   // Perceptual hash, nil until computed
+	DHash     *uint64         `json:"-"`                   // Difference hash, nil until computed
+	Login     string          `json:"login"`               // Owner branding image
+	Claim     *ImageClaim     `json:"claim,omitempty"`     // Staff member reviewing a pending image
+	Dossier   *DossierSummary `json:"dossier,omitempty"`   // Owner's moderation history, on pending images shown to staff
+	Approvals *ApprovalTally  `json:"approvals,omitempty"` // Staff approvals of the pending version
+	Slot      string          `json:"slot"`                // Branding slot, empty for the default branding
+	Starts    *time.Time      `json:"starts_at,omitempty"` // Scheduled window start
+	Ends      *time.Time      `json:"ends_at,omitempty"`   // Scheduled window end
+	Schedule  string          `json:"schedule,omitempty"`  // Scheduled branding lifecycle
+}
+
+// theme the image is a variant for, empty for any other slot
+func (i *Img) Theme() string {
+	if theme, found := strings.CutPrefix(i.Slot, themeSlotPrefix); found {
+		return theme
+	}
+
+	return ""
+}
+
+// organization the image is the branding of, 0 for any other slot
+func (i *Img) OrgID() uint64 {
+	if id, found := strings.CutPrefix(i.Slot, orgSlotPrefix); found {
+		if orgId, err := strconv.ParseUint(id, 10, 64); err == nil {
+			return orgId
+		}
+	}
+
+	return 0
+}
+
+// whether the image only applies during a window
+func (i *Img) Scheduled() bool {
+	return i.Starts != nil && i.Ends != nil
+}
+
+// whether the image may be served at a given time
+func (i *Img) LiveAt(t time.Time) bool {
+	if i.Pending {
+		return false
+	}
+
+	return !i.Scheduled() || (!t.Before(*i.Starts) && t.Before(*i.Ends))
+}
+
+// when what the image shows last changed, a scheduled branding changes again once its window starts
+func (i *Img) Updated() time.Time {
+	if i.Scheduled() && i.Starts.After(i.Created) {
+		return *i.Starts
+	}
+
+	return i.Created
+}
+
+// name of the image file in the CDN folder
+func (i *Img) FileName() string {
+	if i.Slot == SlotDefault {
+		// imported legacy images keep the PNG they were published as
+		if i.Legacy {
+			return fmt.Sprintf("%d.png", i.UserID)
+		}
+
+		return fmt.Sprintf("%d.webp", i.UserID)
+	}
+
+	// the organization's file stays put whichever owner submits it
+	if i.OrgID() != 0 {
+		return i.Slot + ".webp"
+	}
+
+	return fmt.Sprintf("%d-%s.webp", i.UserID, i.Slot)
+}
+
+// Staff approvals a pending image has and needs
+type ApprovalTally struct {
+	Votes  int `json:"votes"`  // Approvals of the current version
+	Quorum int `json:"quorum"` // Approvals needed to publish
+}
+
+// Review lease a staff member holds on a pending image
+type ImageClaim struct {
+	ImageID    uint64    `json:"image_id"`    // Claimed image ID
+	StaffID    uint64    `json:"staff_id"`    // Reviewing staff member
+	StaffLogin string    `json:"staff_login"` // Reviewing staff member username
+	Claimed    time.Time `json:"claimed_at"`  // Claimed or last renewed
+	Expires    time.Time `json:"expires_at"`  // Lease runs out
+}
+
+// content type of a stored image read from its first bytes, uploads are kept in whatever format they came in
+func SniffImageType(r io.ReadSeeker) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// serves a stored image file with the content type its bytes have, whatever its name says
+func ServeImageFile(w http.ResponseWriter, r *http.Request, path string) {
+	if f, err := os.Open(path); err == nil {
+		if contentType, err := SniffImageType(f); err == nil {
+			w.Header().Set("Content-Type", contentType)
+		}
+		f.Close()
+	}
+
+	http.ServeFile(w, r, path)
 }
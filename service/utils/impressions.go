@@ -0,0 +1,28 @@
+package utils
+
+import "time"
+
+// Impressions of brandings, counted per developer, mod and day without anything about the players who saw them
+type ImpressionCount struct {
+	UserID uint64 `json:"user_id,omitempty"` // Developer whose branding was served
+	Login  string `json:"login,omitempty"`   // Developer GitHub username
+	ModID  string `json:"mod_id,omitempty"`  // Mod the branding was requested for, empty for none
+	Day    string `json:"day,omitempty"`     // UTC day, as YYYY-MM-DD
+	Count  uint64 `json:"count"`             // Times the branding was served
+}
+
+// A developer's impressions since a day
+type ImpressionStats struct {
+	Since time.Time          `json:"since"` // First day counted
+	Total uint64             `json:"total"` // Impressions across every mod and day
+	Days  []*ImpressionCount `json:"days"`  // Totals per day, oldest first
+	Mods  []*ImpressionCount `json:"mods"`  // Totals per mod, most seen first
+}
+
+// Most seen developers and mods since a day
+type ImpressionLeaders struct {
+	Since      time.Time          `json:"since"`      // First day counted
+	Total      uint64             `json:"total"`      // Impressions of every branding
+	Developers []*ImpressionCount `json:"developers"` // Most seen developers first
+	Mods       []*ImpressionCount `json:"mods"`       // Most seen mods first
+}
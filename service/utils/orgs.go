@@ -0,0 +1,29 @@
+package utils
+
+import "time"
+
+// Roles a user can hold in an organization
+const (
+	OrgRoleMember = "member" // May show the team branding
+	OrgRoleOwner  = "owner"  // Manages members, mods and the team branding
+)
+
+// Database row for organizations, the team identities several developers publish under
+type Org struct {
+	ID        uint64           `json:"id"`                // Organization ID
+	Name      string           `json:"name"`              // Unique lowercase name
+	CreatedBy uint64           `json:"created_by"`        // User who created it
+	Created   time.Time        `json:"created_at"`        // First created
+	Mods      []string         `json:"mods"`              // Geode mods listing the members
+	Members   []*OrgMembership `json:"members,omitempty"` // Members and their roles
+}
+
+// Database row for organization members
+type OrgMembership struct {
+	OrgID   uint64    `json:"org_id"`          // Organization ID
+	UserID  uint64    `json:"user_id"`         // Member GitHub user ID
+	Login   string    `json:"login,omitempty"` // Member GitHub username
+	Role    string    `json:"role"`            // Member or owner
+	OptIn   bool      `json:"opt_in"`          // Shows the team branding instead of their own
+	Created time.Time `json:"created_at"`      // Joined
+}
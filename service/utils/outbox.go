@@ -0,0 +1,23 @@
+package utils
+
+import "time"
+
+// Delivery states of queued Discord notifications
+const (
+	OutboxPending = "pending" // Waiting for its next attempt
+	OutboxSent    = "sent"    // Delivered
+	OutboxDead    = "dead"    // Gave up after too many failures
+)
+
+// Database row for queued Discord notifications
+type OutboxMessage struct {
+	ID          uint64     `json:"id"`              // Message ID
+	Channel     string     `json:"channel"`         // Webhook the message goes to
+	Payload     string     `json:"payload"`         // Encoded message
+	Status      string     `json:"status"`          // Delivery state
+	Attempts    uint       `json:"attempts"`        // Failed deliveries so far
+	LastError   string     `json:"last_error"`      // Error of the last failed delivery
+	NextAttempt time.Time  `json:"next_attempt_at"` // Earliest next delivery
+	Created     time.Time  `json:"created_at"`      // First queued
+	Sent        *time.Time `json:"sent_at"`         // Delivered at
+}
@@ -0,0 +1,47 @@
+package utils
+
+import "time"
+
+// Reasons players can report a branding for
+const (
+	ReportOffensive     = "offensive"     // Hateful, sexual or otherwise offensive content
+	ReportImpersonation = "impersonation" // Pretends to be another developer
+	ReportCopyright     = "copyright"     // Uses someone else's work
+	ReportSpam          = "spam"          // Advertising or unrelated content
+	ReportOther         = "other"         // Anything else, explained in the note
+)
+
+var ReportCategories = []string{
+	ReportOffensive,
+	ReportImpersonation,
+	ReportCopyright,
+	ReportSpam,
+	ReportOther,
+}
+
+// Report states
+const (
+	ReportOpen     = "open"     // Waiting for staff
+	ReportResolved = "resolved" // Handled by staff
+)
+
+// Database row for a player report against a live branding
+type Report struct {
+	ID       uint64    `json:"id"`         // Report ID
+	ImageID  uint64    `json:"image_id"`   // Reported image
+	Category string    `json:"category"`   // Reason category
+	Note     string    `json:"note"`       // Optional details from the reporter
+	Status   string    `json:"status"`     // Report state
+	Created  time.Time `json:"created_at"` // Reported at
+}
+
+// Open reports against one image, as shown in the staff report queue
+type ReportGroup struct {
+	Image      *Img           `json:"image"`      // Reported image
+	Login      string         `json:"login"`      // Owner username
+	Count      int            `json:"count"`      // Open reports
+	Categories map[string]int `json:"categories"` // Open reports per category
+	Notes      []string       `json:"notes"`      // Notes left by reporters
+	First      time.Time      `json:"first_at"`   // Oldest open report
+	Last       time.Time      `json:"last_at"`    // Newest open report
+}
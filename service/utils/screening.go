@@ -0,0 +1,18 @@
+package utils
+
+// Problem found by a pre-screening rule
+type ScreenFlag struct {
+	Rule    string `json:"rule"`    // Rule that raised it
+	Message string `json:"message"` // What was found
+	Score   int    `json:"score"`   // Risk added to the submission
+	Hard    bool   `json:"hard"`    // Configured to reject the submission outright
+}
+
+// Outcome of pre-screening a submission
+type Screening struct {
+	Flags    []ScreenFlag `json:"flags"`      // Problems found
+	Score    int          `json:"risk_score"` // Summed risk, capped at 100
+	Rejected bool         `json:"rejected"`   // A hard failure was found
+	PHash    *uint64      `json:"-"`          // Perceptual hash of the upload, nil if it could not be decoded
+	DHash    *uint64      `json:"-"`          // Difference hash of the upload, nil if it could not be decoded
+}
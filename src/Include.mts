@@ -30,4 +30,49 @@ export interface Image {
     created_at?: string;
     /** Under review */
     pending?: boolean;
+    /** Bumped on every change, sent back with moderation decisions */
+    version?: number;
+    /** Branding slot, empty for the default branding */
+    slot?: string;
+    /** Scheduled window start */
+    starts_at?: string;
+    /** Scheduled window end */
+    ends_at?: string;
+    /** Scheduled branding lifecycle */
+    schedule?: 'waiting' | 'live' | 'expired';
+};
+
+export interface ImageClaim {
+    /** Claimed brand image ID */
+    image_id: number;
+    /** Reviewing staff member ID */
+    staff_id: number;
+    /** Reviewing staff member username */
+    staff_login: string;
+    /** Claimed or last renewed */
+    claimed_at: string;
+    /** Lease runs out */
+    expires_at: string;
+};
+
+export interface DossierSummary {
+    /** Staff notes */
+    notes: number;
+    /** Newest staff note */
+    latest_note: string;
+    /** Past submissions */
+    submissions: number;
+    /** Submissions turned down by staff or screening */
+    rejected: number;
+    /** Live images removed or unpublished by staff */
+    removed: number;
+    /** Player reports against their branding */
+    reports: number;
+};
+
+export interface ApprovalTally {
+    /** Staff approvals of the pending version */
+    votes: number;
+    /** Approvals needed to publish */
+    quorum: number;
 };
\ No newline at end of file
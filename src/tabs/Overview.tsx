@@ -14,6 +14,7 @@ interface OverviewProps {
 function Overview({ user }: OverviewProps) {
     const [images, setImages] = useState<Image[]>([]);
     const [loading, setLoading] = useState(true);
+    const [upcoming, setUpcoming] = useState<Image[]>([]);
 
     useEffect(() => {
         const fetchImages = async () => {
@@ -32,7 +33,24 @@ function Overview({ user }: OverviewProps) {
             };
         };
 
-        if (user) fetchImages();
+        const fetchUpcoming = async () => {
+            try {
+                const res = await fetch('/brand/scheduled');
+                if (res.ok) {
+                    const data = await res.json();
+                    setUpcoming(data || []);
+                } else {
+                    console.error("Failed to fetch scheduled brandings");
+                };
+            } catch (error) {
+                console.error(error);
+            };
+        };
+
+        if (user) {
+            fetchImages();
+            fetchUpcoming();
+        };
     }, [user]);
 
     return (
@@ -100,6 +118,11 @@ function Overview({ user }: OverviewProps) {
                                         </Box>
                                     </Box>
                                     <CardContent sx={{ flexGrow: 1, display: 'flex', flexDirection: 'column', gap: 1 }}>
+                                        {img.slot?.startsWith('theme-') && (
+                                            <Typography variant="caption" sx={{ color: 'rgba(255,255,255,0.6)' }}>
+                                                Variant: {img.slot.replace(/^theme-/, '')} theme
+                                            </Typography>
+                                        )}
                                         <Typography variant="caption" sx={{ color: 'rgba(255,255,255,0.6)' }}>
                                             Submitted: {new Date(img.created_at || '').toLocaleDateString()}
                                         </Typography>
@@ -110,6 +133,31 @@ function Overview({ user }: OverviewProps) {
                     </Grid>
                 )}
             </Paper>
+
+            {upcoming.length > 0 && (
+                <Paper sx={{ p: 4, mt: 3, bgcolor: 'rgba(0,0,0,0.4)', color: 'white' }}>
+                    <Typography variant="h6" gutterBottom sx={{ mb: 2, textAlign: 'left', borderBottom: '1px solid rgba(255,255,255,0.1)', pb: 1 }}>
+                        Scheduled Branding
+                    </Typography>
+                    {upcoming.map((img) => (
+                        <Box key={img.id} sx={{ display: 'flex', alignItems: 'center', gap: 2, py: 1 }}>
+                            <Box component="img" src={img.image_url} alt={`Branding ${img.id}`} sx={{ height: 48, width: 96, objectFit: 'contain', bgcolor: 'rgba(0,0,0,0.2)' }} />
+                            <Box sx={{ flexGrow: 1 }}>
+                                <Typography variant="body2">{img.slot?.replace(/^scheduled-/, '')}</Typography>
+                                <Typography variant="caption" sx={{ color: 'rgba(255,255,255,0.6)' }}>
+                                    {new Date(img.starts_at || '').toLocaleString()} to {new Date(img.ends_at || '').toLocaleString()}
+                                </Typography>
+                            </Box>
+                            <Chip
+                                label={img.pending ? 'Pending' : img.schedule === 'live' ? 'Live' : 'Upcoming'}
+                                color={img.pending ? 'warning' : img.schedule === 'live' ? 'success' : 'info'}
+                                size="small"
+                                sx={{ color: 'white' }}
+                            />
+                        </Box>
+                    ))}
+                </Paper>
+            )}
         </Box>
     );
 };
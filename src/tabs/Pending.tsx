@@ -3,10 +3,13 @@ import { Box, Paper, Typography, Table, TableBody, TableCell, TableContainer, Ta
 import CheckCircleIcon from '@mui/icons-material/CheckCircle';
 import DeleteIcon from '@mui/icons-material/Delete';
 
-import type { Image } from '../Include.mjs';
+import type { ApprovalTally, DossierSummary, Image, ImageClaim } from '../Include.mjs';
 
 interface Img extends Image {
     login: string;
+    claim?: ImageClaim;
+    dossier?: DossierSummary;
+    approvals?: ApprovalTally;
 };
 
 function Pending() {
@@ -28,23 +31,50 @@ function Pending() {
         };
     };
 
-    useEffect(() => {
-        const load = async () => {
-            const data = await fetchImages();
-            setImages(data);
-        };
+    const reload = async () => {
+        const data = await fetchImages();
+        setImages(data);
+    };
 
-        load();
+    useEffect(() => {
+        reload();
     }, []);
 
-    const handleAccept = async (id: number) => {
+    const handleClaim = async (id: number) => {
         try {
-            const res = await fetch(`/brand/pending/accept?id=${id}`, {
+            const res = await fetch(`/brand/pending/claim?id=${id}`, {
+                method: 'POST'
+            });
+            if (!res.ok) {
+                const errorText = await res.text();
+                setMessage({ type: 'error', text: `Failed to claim: ${errorText}` });
+            };
+
+            reload();
+        } catch (error) {
+            setMessage({ type: 'error', text: 'An unexpected error occurred.' });
+            console.error(error);
+        }
+    };
+
+    const handleAccept = async (img: Img) => {
+        try {
+            const res = await fetch(`/brand/pending/accept?id=${img.id}&version=${img.version ?? 0}`, {
                 method: 'POST'
             });
             if (res.ok) {
-                setMessage({ type: 'success', text: 'Image accepted successfully!' });
-                fetchImages();
+                const data: Img = await res.json();
+                if (data.pending && data.approvals) {
+                    setMessage({ type: 'success', text: `Approval recorded, ${data.approvals.votes} of ${data.approvals.quorum} approvals.` });
+                } else {
+                    setMessage({ type: 'success', text: 'Image accepted successfully!' });
+                };
+
+                reload();
+            } else if (res.status === 409) {
+                const errorText = await res.text();
+                setMessage({ type: 'error', text: errorText || 'This submission changed or is being reviewed by someone else.' });
+                reload();
             } else {
                 const errorText = await res.text();
                 setMessage({ type: 'error', text: `Failed to accept: ${errorText}` });
@@ -55,14 +85,17 @@ function Pending() {
         }
     };
 
-    const handleDelete = async (id: number) => {
+    const handleDelete = async (img: Img) => {
         try {
-            const res = await fetch(`/brand/delete?id=${id}`, {
+            const res = await fetch(`/brand/delete?id=${img.id}&version=${img.version ?? 0}`, {
                 method: 'DELETE'
             });
             if (res.ok) {
                 setMessage({ type: 'success', text: 'Image deleted successfully!' });
-                fetchImages();
+                reload();
+            } else if (res.status === 409) {
+                setMessage({ type: 'error', text: 'This submission changed or is being reviewed by someone else.' });
+                reload();
             } else {
                 const errorText = await res.text();
                 setMessage({ type: 'error', text: `Failed to delete: ${errorText}` });
@@ -91,6 +124,7 @@ function Pending() {
                             <TableCell sx={{ color: 'white', fontWeight: 'bold' }}>Username</TableCell>
                             <TableCell sx={{ color: 'white', fontWeight: 'bold' }}>Image</TableCell>
                             <TableCell sx={{ color: 'white', fontWeight: 'bold' }}>Created At</TableCell>
+                            <TableCell sx={{ color: 'white', fontWeight: 'bold' }}>Reviewer</TableCell>
                             <TableCell sx={{ color: 'white', fontWeight: 'bold' }}>Action</TableCell>
                         </TableRow>
                     </TableHead>
@@ -99,7 +133,14 @@ function Pending() {
                             <TableRow key={img.id} hover sx={{ '&:hover': { bgcolor: 'rgba(255,255,255,0.05)' } }}>
                                 <TableCell sx={{ color: 'white' }}>{img.id}</TableCell>
                                 <TableCell sx={{ color: 'white' }}>{img.user_id}</TableCell>
-                                <TableCell sx={{ color: 'white' }}><a href={`https://www.github.com/${img.login}/`} target="_blank">{img.login}</a></TableCell>
+                                <TableCell sx={{ color: 'white' }}>
+                                    <a href={`https://www.github.com/${img.login}/`} target="_blank">{img.login}</a>
+                                    {img.dossier && (
+                                        <Typography variant="caption" component="div" title={img.dossier.latest_note} sx={{ color: 'rgba(255,255,255,0.7)' }}>
+                                            {img.dossier.submissions} submitted, {img.dossier.rejected} rejected, {img.dossier.removed} removed, {img.dossier.reports} reports, {img.dossier.notes} notes
+                                        </Typography>
+                                    )}
+                                </TableCell>
                                 <TableCell sx={{ color: 'white' }}>
                                     <Box
                                         component="img"
@@ -114,10 +155,34 @@ function Pending() {
                                         }}
                                         onClick={() => window.open(img.image_url, '_blank')}
                                     />
+                                    {img.slot?.startsWith('theme-') && (
+                                        <Typography variant="caption" component="div" sx={{ color: 'rgba(255,255,255,0.7)' }}>
+                                            {img.slot.replace(/^theme-/, '')} theme variant
+                                        </Typography>
+                                    )}
+                                    {img.starts_at && img.ends_at && (
+                                        <Typography variant="caption" component="div" sx={{ color: 'rgba(255,255,255,0.7)' }}>
+                                            Scheduled {new Date(img.starts_at).toLocaleDateString()} to {new Date(img.ends_at).toLocaleDateString()}
+                                        </Typography>
+                                    )}
                                 </TableCell>
                                 <TableCell sx={{ color: 'white' }}>
                                     {new Date(img.created_at || "").toLocaleString()}
                                 </TableCell>
+                                <TableCell sx={{ color: 'white' }}>
+                                    {img.claim ? (
+                                        <a href={`https://www.github.com/${img.claim.staff_login}/`} target="_blank">{img.claim.staff_login}</a>
+                                    ) : (
+                                        <Button
+                                            variant="outlined"
+                                            size="small"
+                                            onClick={() => handleClaim(img.id)}
+                                            sx={{ textTransform: 'none', color: 'white', borderColor: 'rgba(255,255,255,0.3)' }}
+                                        >
+                                            Review
+                                        </Button>
+                                    )}
+                                </TableCell>
                                 <TableCell sx={{ color: 'white' }}>
                                     <Box sx={{ display: 'flex', gap: 1 }}>
                                         <Button
@@ -125,17 +190,17 @@ function Pending() {
                                             color="success"
                                             size="small"
                                             startIcon={<CheckCircleIcon />}
-                                            onClick={() => handleAccept(img.id)}
+                                            onClick={() => handleAccept(img)}
                                             sx={{ textTransform: 'none' }}
                                         >
-                                            Accept
+                                            {img.approvals && img.approvals.quorum > 1 ? `Accept (${img.approvals.votes}/${img.approvals.quorum})` : 'Accept'}
                                         </Button>
                                         <Button
                                             variant="contained"
                                             color="error"
                                             size="small"
                                             startIcon={<DeleteIcon />}
-                                            onClick={() => handleDelete(img.id)}
+                                            onClick={() => handleDelete(img)}
                                             sx={{ textTransform: 'none' }}
                                         >
                                             Reject
@@ -146,7 +211,7 @@ function Pending() {
                         ))}
                         {images.length === 0 && (
                             <TableRow>
-                                <TableCell colSpan={6} align="center" sx={{ color: 'rgba(255,255,255,0.7)', py: 4 }}>
+                                <TableCell colSpan={7} align="center" sx={{ color: 'rgba(255,255,255,0.7)', py: 4 }}>
                                     No pending images found.
                                 </TableCell>
                             </TableRow>
@@ -1,6 +1,6 @@
 import { useState, type ChangeEvent } from "react";
 
-import { Box, Button, Typography, Paper, Alert, Snackbar, CircularProgress, Dialog, DialogContent } from '@mui/material';
+import { Box, Button, Typography, Paper, Alert, Snackbar, CircularProgress, Dialog, DialogContent, TextField, FormControlLabel, Switch, ToggleButton, ToggleButtonGroup } from '@mui/material';
 
 import AddPhotoAlternateIcon from '@mui/icons-material/AddPhotoAlternate';
 import CloudUploadIcon from '@mui/icons-material/CloudUpload';
@@ -11,6 +11,11 @@ function Submission() {
     const [uploading, setUploading] = useState(false);
     const [message, setMessage] = useState<{ type: 'success' | 'error', text: string } | null>(null);
     const [openPreview, setOpenPreview] = useState(false);
+    const [theme, setTheme] = useState<'' | 'light' | 'dark'>('');
+    const [scheduled, setScheduled] = useState(false);
+    const [scheduleName, setScheduleName] = useState('');
+    const [startsAt, setStartsAt] = useState('');
+    const [endsAt, setEndsAt] = useState('');
 
     const handleFileChange = (event: ChangeEvent<HTMLInputElement>) => {
         if (event.target.files && event.target.files[0]) {
@@ -27,6 +32,15 @@ function Submission() {
         const formData = new FormData();
         formData.append('image-upload', file);
 
+        if (theme) {
+            formData.append('theme', theme);
+        } else if (scheduled) {
+            // datetime-local inputs are in local time, the service expects RFC 3339
+            formData.append('schedule', scheduleName);
+            formData.append('starts_at', new Date(startsAt).toISOString());
+            formData.append('ends_at', new Date(endsAt).toISOString());
+        }
+
         try {
             const response = await fetch('/brand/submit', {
                 method: 'POST',
@@ -37,6 +51,8 @@ function Submission() {
                 setMessage({ type: 'success', text: 'Brand image submitted successfully!' });
                 setFile(null);
                 setPreview(null);
+                setScheduled(false);
+                setTheme('');
             } else {
                 const errorText = await response.text();
                 setMessage({ type: 'error', text: `Upload failed: ${errorText}` });
@@ -133,10 +149,56 @@ function Submission() {
                     </Box>
                 )}
 
+                <ToggleButtonGroup
+                    value={theme}
+                    exclusive
+                    onChange={(_, value) => value !== null && setTheme(value)}
+                    size="small"
+                    sx={{ '& .MuiToggleButton-root': { color: 'rgba(255,255,255,0.7)', borderColor: 'rgba(255,255,255,0.3)' } }}
+                >
+                    <ToggleButton value="">Default</ToggleButton>
+                    <ToggleButton value="light">Light theme variant</ToggleButton>
+                    <ToggleButton value="dark">Dark theme variant</ToggleButton>
+                </ToggleButtonGroup>
+
+                <FormControlLabel
+                    disabled={!!theme}
+                    control={<Switch checked={scheduled && !theme} onChange={(e) => setScheduled(e.target.checked)} color="secondary" />}
+                    label="Only show during a scheduled window, like a seasonal event"
+                />
+
+                {scheduled && !theme && (
+                    <Box sx={{ display: 'flex', flexWrap: 'wrap', gap: 2, justifyContent: 'center' }}>
+                        <TextField
+                            label="Schedule name"
+                            value={scheduleName}
+                            onChange={(e) => setScheduleName(e.target.value.toLowerCase())}
+                            helperText="Lowercase letters, digits and dashes, reusing a name replaces it"
+                            size="small"
+                        />
+                        <TextField
+                            label="Starts"
+                            type="datetime-local"
+                            value={startsAt}
+                            onChange={(e) => setStartsAt(e.target.value)}
+                            slotProps={{ inputLabel: { shrink: true } }}
+                            size="small"
+                        />
+                        <TextField
+                            label="Ends"
+                            type="datetime-local"
+                            value={endsAt}
+                            onChange={(e) => setEndsAt(e.target.value)}
+                            slotProps={{ inputLabel: { shrink: true } }}
+                            size="small"
+                        />
+                    </Box>
+                )}
+
                 <Button
                     variant="contained"
                     onClick={handleSubmit}
-                    disabled={!file || uploading}
+                    disabled={!file || uploading || (scheduled && !theme && (!scheduleName || !startsAt || !endsAt))}
                     sx={{
                         mt: 2,
                         px: 5,